@@ -16,8 +16,10 @@ import (
 )
 
 var (
-	cfgFile  string
-	noRemote bool
+	cfgFile         string
+	noRemote        bool
+	script          string
+	continueOnError bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -37,7 +39,7 @@ This way user has live representation of the activity on local or remote system,
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		zterm.Main(!noRemote)
+		zterm.Main(!noRemote, script, continueOnError)
 	},
 }
 
@@ -69,6 +71,9 @@ func init() {
 
 	rootCmd.Flags().BoolVar(&noRemote, "no-remote", false, "do not connect to remote server")
 
+	rootCmd.Flags().StringVar(&script, "script", "", "file with console commands executed at startup")
+	rootCmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "continue with the next command of the script when command fails")
+
 	rootCmd.Flags().Int("refresh-interval", 5, "refresh interval in seconds used to get new data (default: 5s)")
 	viper.BindPFlag("server.refresh", rootCmd.Flags().Lookup("refresh-interval"))
 
@@ -0,0 +1,268 @@
+package zterm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "unsafe" // gocui screen
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/gdamore/tcell/v2"
+)
+
+// AlertRule notifies user when new output of the view matches the regex (`alerts:` of the view)
+type AlertRule struct {
+	Name      string `mapstructure:"name,omitempty" yaml:"name,omitempty"`
+	Regex     string `mapstructure:"regex" yaml:"regex"`
+	Threshold int    `mapstructure:"threshold,omitempty" yaml:"threshold,omitempty"` // matches in one refresh (1 by default)
+	Rate      string `mapstructure:"rate,omitempty" yaml:"rate,omitempty"`           // matches in time window (like 5/10m)
+	Cooldown  string `mapstructure:"cooldown,omitempty" yaml:"cooldown,omitempty"`   // minimal time between notifications (1m by default)
+	Command   string `mapstructure:"command,omitempty" yaml:"command,omitempty"`     // local command executed when the rule fires
+}
+
+const (
+	alertCooldown = time.Minute     // default cooldown of alert rule
+	alertFlash    = 4 * time.Second // view frame flashes after alert
+	alertPopup    = "alert-popup"
+)
+
+// alertRule is a runtime state of the alert rule
+type alertRule struct {
+	AlertRule
+	re        *regexp.Regexp
+	threshold int
+	window    time.Duration // time window of the rate (0 counts matches in one refresh)
+	cooldown  time.Duration
+	hits      []time.Time // times of matches in the window (or in the current refresh)
+	fired     time.Time
+}
+
+// viewAlerts are alert rules of the view with output lines of the previous refresh (only new lines are matched)
+type viewAlerts struct {
+	rules []*alertRule
+	prev  map[string]int // count of the lines in previous refresh
+	curr  map[string]int // count of the lines in current refresh
+	sgr   sgrState
+}
+
+// newAlertRule checks the rule configuration and returns the rule
+func newAlertRule(ar AlertRule) (*alertRule, error) {
+	re, err := regexp.Compile(ar.Regex)
+	if err != nil || ar.Regex == "" {
+		return nil, fmt.Errorf("alert: invalid regex '%s'", ar.Regex)
+	}
+	r := &alertRule{AlertRule: ar, re: re, threshold: ar.Threshold, cooldown: alertCooldown}
+	if r.Name == "" {
+		r.Name = ar.Regex
+	}
+	if ar.Rate != "" {
+		if ar.Threshold > 0 {
+			return nil, fmt.Errorf("alert %s: use threshold or rate, not both", r.Name)
+		}
+		count, window, ok := strings.Cut(ar.Rate, "/")
+		n, nerr := strconv.Atoi(count)
+		d, derr := time.ParseDuration(window)
+		if !ok || nerr != nil || derr != nil || n <= 0 || d <= 0 {
+			return nil, fmt.Errorf("alert %s: invalid rate '%s' (use count/duration like 5/10m)", r.Name, ar.Rate)
+		}
+		r.threshold, r.window = n, d
+	}
+	if r.threshold <= 0 {
+		r.threshold = 1
+	}
+	if ar.Cooldown != "" {
+		d, err := time.ParseDuration(ar.Cooldown)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("alert %s: invalid cooldown '%s' (use duration like 5m)", r.Name, ar.Cooldown)
+		}
+		r.cooldown = d
+	}
+	return r, nil
+}
+
+// newViewAlerts returns alert rules of the view (nil when there are no rules)
+func newViewAlerts(rules []AlertRule) (*viewAlerts, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	va := &viewAlerts{prev: map[string]int{}, curr: map[string]int{}}
+	for _, ar := range rules {
+		r, err := newAlertRule(ar)
+		if err != nil {
+			return nil, err
+		}
+		va.rules = append(va.rules, r)
+	}
+	return va, nil
+}
+
+// next starts new refresh (lines of current refresh become previous lines)
+func (va *viewAlerts) next() {
+	va.prev, va.curr = va.curr, map[string]int{}
+	va.sgr = sgrState{}
+	for _, r := range va.rules {
+		if r.window == 0 {
+			r.hits = nil
+		}
+	}
+}
+
+// scan matches new lines of the output with the rules and fires alerts
+func (va *viewAlerts) scan(ws *WidgetStack, text string) {
+	now := time.Now()
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		line = parseAnsi(line, &va.sgr).String()
+		va.curr[line]++
+		if va.curr[line] <= va.prev[line] {
+			// line was in previous refresh
+			continue
+		}
+		for _, r := range va.rules {
+			loc := r.re.FindStringIndex(line)
+			if loc == nil {
+				continue
+			}
+			if count, ok := r.hit(now); ok {
+				ws.fireAlert(r, line, line[loc[0]:loc[1]], count)
+			}
+		}
+	}
+}
+
+// hit counts the match and returns true when the rule fires (threshold is reached and it's not in cooldown)
+func (r *alertRule) hit(now time.Time) (int, bool) {
+	r.hits = append(r.hits, now)
+	if r.window > 0 {
+		// keep only matches in the time window
+		for len(r.hits) > 0 && now.Sub(r.hits[0]) > r.window {
+			r.hits = r.hits[1:]
+		}
+	}
+	count := len(r.hits)
+	if count < r.threshold || (!r.fired.IsZero() && now.Sub(r.fired) < r.cooldown) {
+		return count, false
+	}
+	r.fired = now
+	r.hits = nil
+	return count, true
+}
+
+// fireAlert notifies user about the alert (flash of the view frame, pop-up, bell and alert command)
+func (ws *WidgetStack) fireAlert(r *alertRule, line, match string, count int) {
+	ws.flash()
+	// notification and bell
+	msg := fmt.Sprintf("alert '%s' in view %s (%d matches at %s):\n%s", r.Name, ws.name, count,
+		time.Now().Format("15:04:05"), strings.TrimSpace(line))
+	addSimplePopupWidget(alertPopup, cError, 1, -4, 0, 3, msg)
+	beep()
+
+	if r.Command == "" {
+		return
+	}
+	// alert command gets match details in environment variables
+	c := exec.Command("sh", "-c", r.Command)
+	c.Env = append(os.Environ(),
+		"ZTERM_ALERT_VIEW="+ws.name,
+		"ZTERM_ALERT_RULE="+r.Name,
+		"ZTERM_ALERT_REGEX="+r.Regex,
+		"ZTERM_ALERT_LINE="+line,
+		"ZTERM_ALERT_MATCH="+match,
+		"ZTERM_ALERT_COUNT="+strconv.Itoa(count),
+		"ZTERM_ALERT_TIME="+time.Now().Format(time.RFC3339),
+	)
+	go func() {
+		if out, err := c.CombinedOutput(); err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				err = fmt.Errorf("%v: %s", err, msg)
+			}
+			appendErrorMsgToView(ws, fmt.Errorf("alert %s: command: %v", r.Name, err))
+		}
+	}()
+}
+
+// flash starts flashing of the view frame (alert during the flash makes it longer).
+// One ticker redraws the frame to change its color until the flash ends.
+func (ws *WidgetStack) flash() {
+	ws.flashUntil = time.Now().Add(alertFlash)
+	if ws.flashStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	ws.flashStop = stop
+	ticker := time.NewTicker(alertFlash / 8)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				gui.Update(func(g *gocui.Gui) error {
+					if ws.flashStop == stop && !time.Now().Before(ws.flashUntil) {
+						close(stop)
+						ws.flashStop = nil
+					}
+					return nil
+				})
+			}
+		}
+	}()
+}
+
+// gocuiScreen is the terminal screen of gocui (it isn't exported, but the bell has to be written by the screen)
+//
+//go:linkname gocuiScreen github.com/awesome-gocui/gocui.screen
+var gocuiScreen tcell.Screen
+
+// beep rings the terminal bell
+func beep() {
+	if gocuiScreen != nil {
+		gocuiScreen.Beep()
+	}
+}
+
+// flashing checks if the view frame should be displayed in alert color (it blinks after alert)
+func (ws *WidgetStack) flashing() bool {
+	now := time.Now()
+	return now.Before(ws.flashUntil) && ws.flashUntil.Sub(now)/(alertFlash/8)%2 == 1
+}
+
+// SetAlerts setup alert rules from view configuration
+func (ws *WidgetStack) SetAlerts(v View) error {
+	va, err := newViewAlerts(v.Alerts)
+	if err != nil {
+		return err
+	}
+	if va != nil && ws.alerts != nil {
+		// keep lines of the output, so new rules match only new lines
+		va.prev, va.curr, va.sgr = ws.alerts.prev, ws.alerts.curr, ws.alerts.sgr
+	}
+	ws.alerts = va
+	return nil
+}
+
+// alertWidget is a widget wrapper which matches output of the job with alert rules of the view
+type alertWidget struct {
+	Widgeter
+	ws *WidgetStack
+}
+
+// Clear starts new refresh for alert rules and clears the widget
+func (aw *alertWidget) Clear() {
+	if aw.ws.alerts != nil {
+		aw.ws.alerts.next()
+	}
+	aw.Widgeter.Clear()
+}
+
+// Print matches the text with alert rules and prints it into the widget
+func (aw *alertWidget) Print(str string) {
+	if aw.ws.alerts != nil && len(str) > 0 {
+		aw.ws.alerts.scan(aw.ws, str)
+	}
+	aw.Widgeter.Print(str)
+}
@@ -0,0 +1,293 @@
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// chainStep is one command of the command chain (`cmd1; cmd2 && cmd3`)
+type chainStep struct {
+	op  string // operator before the command (`;` or `&&`, empty for the first one)
+	cmd string
+}
+
+const maxExpandDepth = 10 // max depth of aliases and macros used in aliases and macros
+
+// chainWidget is a widget wrapper which keeps connection of the command started in the chain (to wait for it)
+type chainWidget struct {
+	Widgeter
+	conn    *RecvConn
+	sources []string // script files which are executed (chain is in the script), to stop recursive `source`
+}
+
+// cmdInfo is a message of successfully executed command (displayed in console as info, not as error)
+type cmdInfo struct {
+	msg string
+}
+
+func (i *cmdInfo) Error() string {
+	return i.msg
+}
+
+// info returns message of successfully executed command
+func info(format string, a ...interface{}) error {
+	return &cmdInfo{fmt.Sprintf(format, a...)}
+}
+
+// isInfo checks if error is only a message of successfully executed command
+func isInfo(err error) bool {
+	var i *cmdInfo
+	return errors.As(err, &i)
+}
+
+// Connect keeps the connection and connects it to the wrapped widget
+func (cw *chainWidget) Connect(conn *RecvConn) {
+	cw.conn = conn
+	cw.Widgeter.Connect(conn)
+}
+
+// splitChain splits command line into commands separated by `;` or `&&` (not in quotes).
+// Only zterm commands (console commands, aliases and macros) are split. Text after shell command or after
+// command which passes the rest of the line to shell (like `remote`) is kept with it, unless it starts
+// with zterm command, so `cd dir && make` or `remote cd /u && ls` are executed as they are.
+func splitChain(line string) []chainStep {
+	steps := []chainStep{}
+	op := ""
+	var quote rune
+	start, cmdStart := 0, 0
+	runes := []rune(line)
+	add := func(end int) {
+		part := strings.TrimSpace(string(runes[start:end]))
+		if len(steps) > 0 && steps[len(steps)-1].cmd != "" {
+			zterm, _ := chainCommand(part)
+			if _, passRest := chainCommand(steps[len(steps)-1].cmd); passRest && !zterm {
+				steps[len(steps)-1].cmd = strings.TrimSpace(string(runes[cmdStart:end]))
+				return
+			}
+		}
+		steps = append(steps, chainStep{op, part})
+		cmdStart = start
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && quote != '\'':
+			i++
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case quote == r:
+			quote = 0
+		case quote == 0 && (r == ';' || (r == '&' && i+1 < len(runes) && runes[i+1] == '&')):
+			add(i)
+			op = string(r)
+			if r == '&' {
+				op = "&&"
+				i++
+			}
+			start = i + 1
+		}
+	}
+	add(len(runes))
+	return steps
+}
+
+// chainCommand checks if the command is zterm command (console command, alias or macro) and if the rest
+// of the command line is passed to shell or server (shell commands, `remote`, `attach`, ...)
+func chainCommand(cmd string) (zterm bool, passRest bool) {
+	cl, err := tokenize(cmd)
+	if err != nil || len(cl.args) == 0 {
+		return false, true
+	}
+	name := cl.args[0]
+	if _, ok := config.Aliases[name]; ok {
+		return true, false
+	}
+	if _, ok := config.Macros[name]; ok {
+		return true, false
+	}
+	if c, ok := cmdRegistry[name]; ok && !c.local {
+		return true, c.passRest
+	}
+	if strings.HasPrefix(name, "fancy:") {
+		return true, true
+	}
+	return false, true
+}
+
+// expandCommand expands aliases and macros in the command line and returns commands to execute
+func expandCommand(line string) ([]chainStep, error) {
+	return expandLine(line, map[string]bool{}, 0)
+}
+
+func expandLine(line string, expanding map[string]bool, depth int) ([]chainStep, error) {
+	if depth > maxExpandDepth {
+		return nil, errors.New("alias: too many nested aliases or macros")
+	}
+	steps := []chainStep{}
+	for _, st := range splitChain(line) {
+		cl, err := tokenize(st.cmd)
+		if err != nil {
+			return nil, fmt.Errorf("parse: %v", err)
+		}
+		if len(cl.args) == 0 {
+			continue
+		}
+		name := cl.args[0]
+		var expanded []string
+		if alias, ok := config.Aliases[name]; ok && !expanding[name] {
+			// alias is replaced by its value (arguments are kept)
+			expanded = []string{strings.TrimSpace(alias + " " + cl.rest(1))}
+		} else if macro, ok := config.Macros[name]; ok && !expanding[name] {
+			// macro commands get arguments as positional parameters
+			for _, m := range macro {
+				expanded = append(expanded, macroParams(m, cl.args[1:]))
+			}
+		} else {
+			steps = append(steps, st)
+			continue
+		}
+
+		expanding[name] = true
+		first := true
+		for _, e := range expanded {
+			sub, err := expandLine(e, expanding, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if len(sub) > 0 {
+				if first {
+					sub[0].op = st.op
+					first = false
+				} else if sub[0].op == "" {
+					sub[0].op = ";"
+				}
+				steps = append(steps, sub...)
+			}
+		}
+		delete(expanding, name)
+	}
+	return steps, nil
+}
+
+// macroParams replace positional parameters in macro command (`$1`..`$9` and `$@` for all arguments).
+// Parameters in single quotes are kept as they are (like in shell), in double quotes arguments are escaped.
+func macroParams(cmd string, args []string) string {
+	var sb strings.Builder
+	var quote rune
+	runes := []rune(cmd)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && quote != '\'' && i+1 < len(runes):
+			sb.WriteRune(r)
+			i++
+			r = runes[i]
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case quote == r:
+			quote = 0
+		case r == '$' && quote != '\'' && i+1 < len(runes) && (runes[i+1] == '@' || (runes[i+1] >= '1' && runes[i+1] <= '9')):
+			params := args
+			if n := int(runes[i+1] - '0'); runes[i+1] != '@' {
+				params = nil
+				if n <= len(args) {
+					params = args[n-1 : n]
+				}
+			}
+			quoted := []string{}
+			for _, p := range params {
+				if quote == '"' {
+					quoted = append(quoted, escapeDoubleQuoted(p))
+				} else {
+					quoted = append(quoted, quoteArg(p))
+				}
+			}
+			sb.WriteString(strings.Join(quoted, " "))
+			i++
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// escapeDoubleQuoted escapes characters which are special in double quotes
+func escapeDoubleQuoted(arg string) string {
+	var sb strings.Builder
+	for _, r := range arg {
+		if strings.ContainsRune("\"\\$`", r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// runChain executes commands one by one (waits for the end of started command) and returns true when the last one failed.
+// Command after `&&` is executed only if previous command didn't fail.
+func runChain(wgm Widgeter, steps []chainStep, sources []string) bool {
+	failed := false
+	for _, st := range steps {
+		if st.op == "&&" && failed {
+			continue
+		}
+		cw := &chainWidget{Widgeter: wgm, sources: sources}
+		done := make(chan error, 1)
+		gui.UpdateAsync(func(g *gocui.Gui) error {
+			err := runCommand(cw, st.cmd)
+			if err != nil {
+				printResult(wgm, err)
+			}
+			done <- err
+			return nil
+		})
+		err := <-done
+		failed = err != nil && !isInfo(err)
+		if cw.conn != nil {
+			cw.conn.WaitEnd()
+			failed = failed || cw.conn.failed
+		}
+	}
+	return failed
+}
+
+// printResult prints message or error of the command into the widget
+func printResult(wgm Widgeter, err error) {
+	if isInfo(err) {
+		wgm.Print(err.Error() + "\n")
+	} else {
+		wgm.Error(err)
+	}
+}
+
+// aliasNames returns sorted names of aliases and macros
+func aliasNames() []string {
+	names := []string{}
+	for name := range config.Aliases {
+		names = append(names, name)
+	}
+	for name := range config.Macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeAlias completes arguments of alias like arguments of the command in alias
+func completeAlias(args []string) []string {
+	alias, ok := config.Aliases[args[0]]
+	if !ok {
+		return nil
+	}
+	cl := tokenizePartial(alias + " ")
+	expanded := append(cl.args[:len(cl.args)-1], args[1:]...)
+	// only aliases of commands (not other aliases)
+	if _, ok := cmdRegistry[expanded[0]]; !ok || len(expanded) < 2 {
+		return nil
+	}
+	return completeCommand(expanded)
+}
@@ -0,0 +1,119 @@
+package zterm
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// withAliases sets aliases and macros of the config for the test
+func withAliases(t *testing.T, aliases map[string]string, macros map[string][]string) {
+	t.Helper()
+	saved := config
+	config.Aliases, config.Macros = aliases, macros
+	t.Cleanup(func() { config = saved })
+}
+
+func TestSplitChain(t *testing.T) {
+	withAliases(t, map[string]string{"jl": "remote jls"}, map[string][]string{"errview": {"addview $1"}})
+	tests := []struct {
+		line string
+		want []chainStep
+	}{
+		{"addview x", []chainStep{{"", "addview x"}}},
+		{"addview x; attach x ls && view x hi-line ERROR", []chainStep{{"", "addview x"}, {";", "attach x ls"}, {"&&", "view x hi-line ERROR"}}},
+		{`view x hi-line 'a;b' && view x hi-word "c && d"`, []chainStep{{"", `view x hi-line 'a;b'`}, {"&&", `view x hi-word "c && d"`}}},
+		{`view x hi-line a\;b`, []chainStep{{"", `view x hi-line a\;b`}}},
+		{"jl; errview x", []chainStep{{"", "jl"}, {";", "errview x"}}},
+		{"addview x; ls -la", []chainStep{{"", "addview x"}, {";", "ls -la"}}},
+		{"addview x;", []chainStep{{"", "addview x"}, {";", ""}}},
+		// shell commands are kept together
+		{"cd dir && make", []chainStep{{"", "cd dir && make"}}},
+		{"for i in 1 2; do echo $i; done", []chainStep{{"", "for i in 1 2; do echo $i; done"}}},
+		{"make && remote deploy; echo done", []chainStep{{"", "make"}, {"&&", "remote deploy; echo done"}}},
+		// rest of the line is passed to the server or shell
+		{"remote cd /u && ls", []chainStep{{"", "remote cd /u && ls"}}},
+		{"attach x make && echo ok; view x hi-line FAIL", []chainStep{{"", "attach x make && echo ok"}, {";", "view x hi-line FAIL"}}},
+		{"fancy:yaml cat a.yml; cat b.yml", []chainStep{{"", "fancy:yaml cat a.yml; cat b.yml"}}},
+	}
+	for _, tt := range tests {
+		if got := splitChain(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitChain(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestExpandLine(t *testing.T) {
+	aliases := map[string]string{
+		"jl":   "remote jls",
+		"ll":   "ls -la",
+		"grep": "grep --color", // alias of itself is not expanded again
+		"up":   "remote cd /u && ls",
+	}
+	macros := map[string][]string{
+		"errview": {"addview $1", "attach $1 remote $2", "view $1 hi-line ERROR"},
+		"twice":   {"jl; jl"},
+	}
+	withAliases(t, aliases, macros)
+	tests := []struct {
+		line string
+		want []chainStep
+	}{
+		{"jl -a", []chainStep{{"", "remote jls -a"}}},
+		{"jl; addview x", []chainStep{{"", "remote jls"}, {";", "addview x"}}},
+		{"ll /tmp", []chainStep{{"", "ls -la /tmp"}}},
+		{"grep x", []chainStep{{"", "grep --color x"}}},
+		{"up", []chainStep{{"", "remote cd /u && ls"}}},
+		{"errview sys zsyslog", []chainStep{{"", "addview sys"}, {";", "attach sys remote zsyslog"}, {";", "view sys hi-line ERROR"}}},
+		{"addview a && errview b c", []chainStep{{"", "addview a"}, {"&&", "addview b"}, {";", "attach b remote c"}, {";", "view b hi-line ERROR"}}},
+		{"twice && addview x", []chainStep{{"", "remote jls"}, {";", "remote jls"}, {"&&", "addview x"}}},
+		{"cd dir && make", []chainStep{{"", "cd dir && make"}}},
+		{"remote cd /u && ls", []chainStep{{"", "remote cd /u && ls"}}},
+		{" ; ", []chainStep{}},
+	}
+	for _, tt := range tests {
+		got, err := expandCommand(tt.line)
+		if err != nil {
+			t.Errorf("expandCommand(%q): %v", tt.line, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandCommand(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestExpandLineErrors(t *testing.T) {
+	aliases := map[string]string{}
+	for i := 0; i <= maxExpandDepth+1; i++ {
+		aliases[fmt.Sprintf("a%d", i)] = fmt.Sprintf("a%d", i+1)
+	}
+	withAliases(t, aliases, nil)
+	for _, line := range []string{"a0", `addview "x`} {
+		if _, err := expandCommand(line); err == nil {
+			t.Errorf("expandCommand(%q): expected error", line)
+		}
+	}
+}
+
+func TestMacroParams(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		args []string
+		want string
+	}{
+		{"addview $1", []string{"jobs"}, "addview jobs"},
+		{"attach $1 remote $2", []string{"log", "cat /tmp/x"}, "attach log remote 'cat /tmp/x'"},
+		{"view $1 hi-line $2", []string{"log"}, "view log hi-line "},
+		{"remote grep $@", []string{"-i", "abend s0c4"}, "remote grep -i 'abend s0c4'"},
+		{"remote awk '{print $1}' $1", []string{"file"}, "remote awk '{print $1}' file"},
+		{"remote echo '$@' $@", []string{"a", "b"}, "remote echo '$@' a b"},
+		{`view x hi-line "$1 $2"`, []string{`say "hi"`, "$HOME"}, `view x hi-line "say \"hi\" \$HOME"`},
+		{`remote echo \$1 $1`, []string{"a"}, `remote echo \$1 a`},
+		{`remote echo "it's $1"`, []string{"a"}, `remote echo "it's a"`},
+		{"echo $10", []string{"a"}, "echo a0"},
+	}
+	for _, tt := range tests {
+		if got := macroParams(tt.cmd, tt.args); got != tt.want {
+			t.Errorf("macroParams(%q, %q) = %q, want %q", tt.cmd, tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package zterm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// sgrState is a state of ANSI SGR (Select Graphic Rendition) attributes.
+// Colors are kept as SGR parameters (like `31`, `38;5;208` or `38;2;255;0;0`).
+type sgrState struct {
+	fg, bg string
+	attrs  [10]bool // font effects indexed by SGR parameter (1 bold, 2 faint, 4 underline, 7 reverse...)
+}
+
+// apply SGR parameters to the state
+func (st *sgrState) apply(params []int) {
+	if len(params) == 0 {
+		*st = sgrState{}
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		switch {
+		case p == 0:
+			*st = sgrState{}
+		case p >= 1 && p <= 9:
+			st.attrs[p] = true
+		case p == 21 || p == 22:
+			st.attrs[1], st.attrs[2] = false, false
+		case p >= 23 && p <= 29:
+			st.attrs[p-20] = false
+		case p >= 30 && p <= 37:
+			st.fg = strconv.Itoa(p)
+		case p == 39:
+			st.fg = ""
+		case p >= 40 && p <= 47:
+			st.bg = strconv.Itoa(p)
+		case p == 49:
+			st.bg = ""
+		case p >= 90 && p <= 97:
+			// bright colors are not supported by gocui, use 256 color version
+			st.fg = "38;5;" + strconv.Itoa(p-90+8)
+		case p >= 100 && p <= 107:
+			st.bg = "48;5;" + strconv.Itoa(p-100+8)
+		case p == 38 || p == 48 || p == 58:
+			col := ""
+			if i+2 < len(params) && params[i+1] == 5 {
+				col = strconv.Itoa(p) + ";5;" + strconv.Itoa(params[i+2])
+				i += 2
+			} else if i+4 < len(params) && params[i+1] == 2 {
+				col = strconv.Itoa(p) + ";2;" + strconv.Itoa(params[i+2]) + ";" +
+					strconv.Itoa(params[i+3]) + ";" + strconv.Itoa(params[i+4])
+				i += 4
+			} else {
+				// malformed, skip the rest
+				return
+			}
+			// underline color (58) is not supported, only its parameters are skipped
+			if p == 38 {
+				st.fg = col
+			} else if p == 48 {
+				st.bg = col
+			}
+		}
+	}
+}
+
+// sequence returns escape sequences setting up the state from scratch.
+// Every attribute has its own sequence, because gocui cannot parse combined ones (like `1;38;5;208`).
+func (st sgrState) sequence() string {
+	var sb strings.Builder
+	sb.WriteString("\x1b[0m")
+	if st.fg != "" {
+		sb.WriteString("\x1b[" + st.fg + "m")
+	}
+	if st.bg != "" {
+		sb.WriteString("\x1b[" + st.bg + "m")
+	}
+	for a, on := range st.attrs {
+		if on {
+			sb.WriteString("\x1b[" + strconv.Itoa(a) + "m")
+		}
+	}
+	return sb.String()
+}
+
+// ansiText is a text with parsed ANSI SGR sequences, where every visible rune has its own style.
+// Other escape sequences (cursor movement, clear line, OSC...) are dropped.
+type ansiText struct {
+	runes  []rune
+	styles []sgrState
+}
+
+// parseAnsi parses text with ANSI escape sequences. State is the style active before the text
+// and it's updated to the style active at the end of the text (so it can continue on the next line).
+func parseAnsi(str string, state *sgrState) ansiText {
+	at := ansiText{}
+	st := sgrState{}
+	if state != nil {
+		st = *state
+	}
+	runes := []rune(str)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\x1b' {
+			at.runes = append(at.runes, runes[i])
+			at.styles = append(at.styles, st)
+			continue
+		}
+		if i+1 >= len(runes) {
+			break
+		}
+		switch runes[i+1] {
+		case '[':
+			// CSI sequence, parameters ends with final byte (0x40-0x7e)
+			j := i + 2
+			for j < len(runes) && (runes[j] < 0x40 || runes[j] > 0x7e) {
+				j++
+			}
+			if j >= len(runes) {
+				i = j
+				break
+			}
+			if runes[j] == 'm' {
+				st.apply(sgrParams(string(runes[i+2 : j])))
+			}
+			i = j
+		case ']':
+			// OSC sequence, ends with BEL or ST (ESC \)
+			j := i + 2
+			for j < len(runes) && runes[j] != '\a' && !(runes[j] == '\x1b' && j+1 < len(runes) && runes[j+1] == '\\') {
+				j++
+			}
+			if j < len(runes) && runes[j] == '\x1b' {
+				j++
+			}
+			i = j
+		case '(', ')', '*', '+':
+			// character set designation (like `ESC ( B` from `tput sgr0`)
+			i += 2
+		default:
+			// two character escape sequence
+			i++
+		}
+	}
+	if state != nil {
+		*state = st
+	}
+	return at
+}
+
+// sgrParams converts SGR parameter string (like `1;31`) to numbers
+func sgrParams(str string) []int {
+	params := []int{}
+	if len(str) == 0 {
+		return params
+	}
+	for _, p := range strings.Split(str, ";") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			n = 0
+		}
+		params = append(params, n)
+	}
+	return params
+}
+
+// String returns visible text without escape sequences
+func (at ansiText) String() string {
+	return string(at.runes)
+}
+
+// stripStyles removes all styles from the text
+func (at ansiText) stripStyles() {
+	for i := range at.styles {
+		at.styles[i] = sgrState{}
+	}
+}
+
+// render returns the text with escape sequences which gocui can display
+func (at ansiText) render() string {
+	var sb strings.Builder
+	last := sgrState{}
+	for i, r := range at.runes {
+		if at.styles[i] != last {
+			last = at.styles[i]
+			sb.WriteString(last.sequence())
+		}
+		sb.WriteRune(r)
+	}
+	if last != (sgrState{}) {
+		sb.WriteString("\x1b[0m")
+	}
+	return sb.String()
+}
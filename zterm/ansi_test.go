@@ -0,0 +1,98 @@
+package zterm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSgrParams(t *testing.T) {
+	tests := []struct {
+		str  string
+		want []int
+	}{
+		{"", []int{}},
+		{"0", []int{0}},
+		{"1;31", []int{1, 31}},
+		{"38;5;208", []int{38, 5, 208}},
+		{";4", []int{0, 4}},
+		{"x;1", []int{0, 1}},
+	}
+	for _, tt := range tests {
+		if got := sgrParams(tt.str); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sgrParams(%q) = %v, want %v", tt.str, got, tt.want)
+		}
+	}
+}
+
+func TestSgrApply(t *testing.T) {
+	tests := []struct {
+		params []int
+		want   string // sequence of the state
+	}{
+		{[]int{}, "\x1b[0m"},
+		{[]int{31}, "\x1b[0m\x1b[31m"},
+		{[]int{1, 4, 42}, "\x1b[0m\x1b[42m\x1b[1m\x1b[4m"},
+		{[]int{1, 31, 0, 33}, "\x1b[0m\x1b[33m"},
+		{[]int{1, 2, 4, 22}, "\x1b[0m\x1b[4m"},
+		{[]int{4, 7, 24, 27}, "\x1b[0m"},
+		{[]int{31, 44, 39, 49}, "\x1b[0m"},
+		{[]int{91, 102}, "\x1b[0m\x1b[38;5;9m\x1b[48;5;10m"},
+		{[]int{38, 5, 208, 1}, "\x1b[0m\x1b[38;5;208m\x1b[1m"},
+		{[]int{48, 2, 255, 0, 10}, "\x1b[0m\x1b[48;2;255;0;10m"},
+		{[]int{32, 38, 2, 1}, "\x1b[0m\x1b[32m"}, // malformed color is skipped
+		{[]int{58, 5, 1}, "\x1b[0m"},             // unsupported parameters are ignored
+	}
+	for _, tt := range tests {
+		st := sgrState{}
+		st.apply(tt.params)
+		if got := st.sequence(); got != tt.want {
+			t.Errorf("apply(%v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestParseAnsi(t *testing.T) {
+	tests := []struct {
+		str    string
+		text   string
+		render string
+	}{
+		{"plain text", "plain text", "plain text"},
+		{"\x1b[31mERROR\x1b[0m: failed", "ERROR: failed", "\x1b[0m\x1b[31mERROR\x1b[0m: failed"},
+		{"\x1b[1;38;5;208mwarn\x1b[m", "warn", "\x1b[0m\x1b[38;5;208m\x1b[1mwarn\x1b[0m"},
+		{"a\x1b[2K\x1b[1Gb", "ab", "ab"},
+		{"\x1b]0;title\x07text", "text", "text"},
+		{"\x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\", "link", "link"},
+		{"\x1b(Bok\x1b", "ok", "ok"},
+		{"日本\x1b[4m語", "日本語", "日本\x1b[0m\x1b[4m語\x1b[0m"},
+		{"cut\x1b[31", "cut", "cut"},
+	}
+	for _, tt := range tests {
+		at := parseAnsi(tt.str, nil)
+		if got := at.String(); got != tt.text {
+			t.Errorf("parseAnsi(%q) text = %q, want %q", tt.str, got, tt.text)
+		}
+		if got := at.render(); got != tt.render {
+			t.Errorf("parseAnsi(%q) render = %q, want %q", tt.str, got, tt.render)
+		}
+	}
+}
+
+func TestParseAnsiState(t *testing.T) {
+	// style continues on the next line
+	st := sgrState{}
+	parseAnsi("\x1b[33mstart of warning", &st)
+	at := parseAnsi("end\x1b[0m.", &st)
+	if got, want := at.render(), "\x1b[0m\x1b[33mend\x1b[0m."; got != want {
+		t.Errorf("render of next line = %q, want %q", got, want)
+	}
+	if st != (sgrState{}) {
+		t.Errorf("state at the end = %q, want reset", st.sequence())
+	}
+
+	at = parseAnsi("\x1b[7mA\x1b[27mB", nil)
+	at.stripStyles()
+	if got := at.render(); got != "AB" {
+		t.Errorf("render without styles = %q, want %q", got, "AB")
+	}
+}
@@ -1,224 +1,323 @@
-package zterm
-
-import (
-	"bufio"
-	"context"
-	"errors"
-	"fmt"
-	"io"
-	"os"
-	"os/exec"
-	"os/user"
-	"path/filepath"
-
-	"github.com/awesome-gocui/gocui"
-	"golang.org/x/crypto/ssh"
-)
-
-// Execute shell command and process output in Widget
-func cmdShell(widget Widgeter, command string) error {
-	// setup widget context
-	ctx, cancel := context.WithCancel(context.Background())
-
-	// handle bash command execution
-	c := exec.CommandContext(ctx, "sh", "-c", command)
-	outPipe, err := c.StdoutPipe()
-	if err != nil {
-		cancel()
-		return err
-	}
-	c.Stderr = c.Stdout // combine stdout and stderr
-	if err := c.Start(); err != nil {
-		cancel()
-		return err
-	}
-
-	// prepare communication channel RecvConn
-	comch := NewRecvConn()
-
-	// setup moderator
-	// go func() {
-	// 	<-comch.sigEnd
-	// 	close(comch.signal)
-	// 	widget.CancelCtx() // ??
-	// }()
-
-	// setup output processing
-	go func() {
-		defer cancel()
-		defer close(comch.err)
-		defer close(comch.outchan)
-
-		scan := bufio.NewScanner(outPipe)
-		// read output
-		for scan.Scan() {
-			select {
-			case <-comch.signal:
-				// killing signal
-				return
-			case comch.outchan <- scan.Text():
-			}
-		}
-		// wait end
-		if err := c.Wait(); err != nil {
-			select {
-			case <-comch.signal:
-				// moderator is already stopped (he is the only one closing this)
-				return
-			case comch.err <- err:
-			}
-		}
-	}()
-
-	connectWidgetOuput(widget, comch)
-
-	return nil
-}
-
-// Execute vim command and use full terminal
-func cmdVim(widget Widgeter, file string) error {
-	gocui.Suspend()
-	defer gocui.Resume()
-
-	// handle bash command execution
-	c := exec.Command("sh", "-c", "vim "+file)
-	c.Stderr = os.Stderr
-	c.Stdin = os.Stdin
-	c.Stdout = os.Stdout
-	if err := c.Run(); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// Execute vim command and use full terminal
-func cmdRVim(widget Widgeter, file string) error {
-	// first download file
-	usr, _ := user.Current()
-	tmpdir := filepath.Join(usr.HomeDir, ".zterm", "tmp")
-	if err := os.MkdirAll(tmpdir, os.ModePerm); err != nil {
-		return err
-	}
-	tmpfile := filepath.Join(tmpdir, dsnPathBase(file))
-	f, err := os.Create(tmpfile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	if err := sshCopyFrom(f, file); err != nil {
-		// TODO: when dataset or member doesn't exist, we could skip this...
-		return err
-	}
-
-	// suspend gocui (for vim)
-	gocui.Suspend()
-	defer gocui.Resume()
-
-	// handle bash command execution
-	// c := exec.Command("sh", "-c", "code --wait "+tmpfile)
-	c := exec.Command("sh", "-c", "vim "+filepath.ToSlash(tmpfile))
-	c.Stderr = os.Stderr
-	c.Stdin = os.Stdin
-	c.Stdout = os.Stdout
-	if err := c.Run(); err != nil {
-		return err
-	}
-	go func() {
-		f, err := os.Open(tmpfile)
-		if err != nil {
-			return
-		}
-		defer f.Close()
-		sshCopyTo(f, file)
-	}()
-
-	return nil
-}
-
-// func run(ctx context.Context) error {
-func cmdSSH(widget Widgeter, cmd string) error {
-	if sshConn == nil {
-		return errors.New("SSH connection not created! Adjust your configuration")
-	}
-
-	session, err := sshConn.NewSession()
-	if err != nil {
-		return fmt.Errorf("cannot open new session: %v", err)
-	}
-
-	stdin, err := session.StdinPipe()
-	if err != nil {
-		return err
-	}
-
-	// start shell
-	if err := session.Shell(); err != nil {
-		return fmt.Errorf("session shell: %s", err)
-	}
-
-	pipeR, pipeW := io.Pipe()
-	session.Stdout = pipeW
-	session.Stderr = pipeW
-
-	// send command
-	if _, err = fmt.Fprintf(stdin, "%s\n", cmd); err != nil {
-		fmt.Println(err)
-		return err
-	}
-	stdin.Close() // just one command
-
-	// prepare communication channel RecvConn
-	comch := NewRecvConn()
-
-	// monitor for cancel and close session if done
-	go func() {
-		<-comch.signal
-		pipeW.Close()
-		session.Close() // TODO: maybe instead of close, call Signal??
-		// session.Signal(ssh.SIGINT) // or maybe ssh.SIGTERM??
-	}()
-
-	// read both stdout/stderr in from one reader
-	go func() {
-		defer close(comch.outchan)
-
-		scan := bufio.NewScanner(pipeR)
-		// read output
-		for scan.Scan() {
-			select {
-			case <-comch.signal:
-				// killing signal
-				return
-			case comch.outchan <- scan.Text():
-			}
-		}
-	}()
-
-	// wait for end
-	go func() {
-		defer close(comch.err)
-		defer session.Close()
-		defer pipeW.Close() // pipe might not be closed and scanner would wait, therefore close
-
-		// wait end
-		if err := session.Wait(); err != nil {
-			efmt := fmt.Errorf("ssh: %v", err.Error())
-			// convert to ssh error if possible
-			if e, ok := err.(*ssh.ExitError); ok && e != nil {
-				efmt = fmt.Errorf("ssh: %v", e.ExitStatus())
-			}
-			// return
-			select {
-			case <-comch.signal:
-				// skip passing error (it's already killed)
-			case comch.err <- efmt:
-			}
-		}
-	}()
-
-	connectWidgetOuput(widget, comch)
-
-	return nil
-}
+package zterm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+
+	"github.com/awesome-gocui/gocui"
+	"golang.org/x/crypto/ssh"
+)
+
+// Execute shell command and process output in Widget
+func cmdShell(widget Widgeter, command string) error {
+	return cmdShellJob(widget, command, nil)
+}
+
+// Execute shell command with job options (directory, environment, shell, timeout) and process output in Widget
+func cmdShellJob(widget Widgeter, command string, opts *jobOptions) error {
+	// setup widget context
+	ctx, cancel := opts.context()
+
+	// handle bash command execution
+	c, err := opts.command(ctx, command)
+	if err != nil {
+		cancel()
+		return err
+	}
+	outPipe, err := c.StdoutPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	c.Stderr = c.Stdout // combine stdout and stderr
+	// commands in console get input from the prompt (others have empty stdin)
+	var inPipe io.WriteCloser
+	if isInteractive(widget) {
+		if inPipe, err = c.StdinPipe(); err != nil {
+			cancel()
+			return err
+		}
+	}
+	if err := startCommand(ctx, c); err != nil {
+		cancel()
+		return err
+	}
+
+	// prepare communication channel RecvConn
+	comch := NewRecvConn()
+	if inPipe != nil {
+		comch.setInput(inPipe)
+	}
+
+	// setup moderator
+	// go func() {
+	// 	<-comch.sigEnd
+	// 	close(comch.signal)
+	// 	widget.CancelCtx() // ??
+	// }()
+
+	// setup output processing
+	go func() {
+		defer cancel()
+		defer close(comch.err)
+		defer close(comch.outchan)
+
+		// read output
+		if comch.input != nil {
+			if !readLines(outPipe, comch) {
+				// killing signal
+				return
+			}
+		} else {
+			scan := bufio.NewScanner(outPipe)
+			for scan.Scan() {
+				select {
+				case <-comch.signal:
+					// killing signal
+					return
+				case comch.outchan <- scan.Text():
+				}
+			}
+		}
+		// wait end
+		if err := c.Wait(); err != nil {
+			select {
+			case <-comch.signal:
+				// moderator is already stopped (he is the only one closing this)
+				return
+			case comch.err <- opts.timeoutError(ctx, err):
+			}
+		}
+	}()
+
+	connectWidgetOuput(widget, comch)
+
+	return nil
+}
+
+// isInteractive checks if the command output goes to the console (such commands get input typed in the prompt)
+func isInteractive(widget Widgeter) bool {
+	if cw, ok := widget.(*chainWidget); ok {
+		widget = cw.Widgeter
+	}
+	_, ok := widget.(*WidgetConsole)
+	return ok
+}
+
+// Execute vim command and use full terminal
+func cmdVim(widget Widgeter, file string) error {
+	gocui.Suspend()
+	defer gocui.Resume()
+
+	// handle bash command execution
+	c := exec.Command("sh", "-c", "vim "+file)
+	c.Stderr = os.Stderr
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	if err := c.Run(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Execute vim command and use full terminal
+func cmdRVim(widget Widgeter, file string) error {
+	// first download file
+	usr, _ := user.Current()
+	tmpdir := filepath.Join(usr.HomeDir, ".zterm", "tmp")
+	if err := os.MkdirAll(tmpdir, os.ModePerm); err != nil {
+		return err
+	}
+	tmpfile := filepath.Join(tmpdir, dsnPathBase(file))
+	f, err := os.Create(tmpfile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := sshCopyFrom(f, file); err != nil {
+		// TODO: when dataset or member doesn't exist, we could skip this...
+		return err
+	}
+
+	// suspend gocui (for vim)
+	gocui.Suspend()
+	defer gocui.Resume()
+
+	// handle bash command execution
+	// c := exec.Command("sh", "-c", "code --wait "+tmpfile)
+	c := exec.Command("sh", "-c", "vim "+filepath.ToSlash(tmpfile))
+	c.Stderr = os.Stderr
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	if err := c.Run(); err != nil {
+		return err
+	}
+	go func() {
+		f, err := os.Open(tmpfile)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		sshCopyTo(f, file)
+	}()
+
+	return nil
+}
+
+// func run(ctx context.Context) error {
+func cmdSSH(widget Widgeter, cmd string) error {
+	return cmdSSHJob(widget, cmd, nil)
+}
+
+// Execute command on remote server with job options (directory, environment, timeout) and process output in Widget
+func cmdSSHJob(widget Widgeter, cmd string, opts *jobOptions) error {
+	ctx, cancel := opts.context()
+	pipeR, pipeW := io.Pipe()
+
+	// start shell with the command (both stdout/stderr go into one pipe)
+	job, err := startSSHJob(ctx, cmd, opts, pipeW, pipeW, isInteractive(widget))
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	// prepare communication channel RecvConn
+	comch := NewRecvConn()
+	if job.stdin != nil {
+		comch.setInput(job.stdin)
+	}
+
+	// monitor for cancel (or timeout) and close session if done
+	job.stopOn(comch.signal, pipeW)
+
+	// read both stdout/stderr in from one reader
+	go func() {
+		defer close(comch.outchan)
+
+		// read output
+		if comch.input != nil {
+			readLines(pipeR, comch)
+			return
+		}
+		scan := bufio.NewScanner(pipeR)
+		for scan.Scan() {
+			select {
+			case <-comch.signal:
+				// killing signal
+				return
+			case comch.outchan <- scan.Text():
+			}
+		}
+	}()
+
+	// wait for end
+	go func() {
+		defer close(comch.err)
+		defer cancel()
+		defer pipeW.Close() // pipe might not be closed and scanner would wait, therefore close
+
+		// wait end
+		if err := job.wait(); err != nil {
+			select {
+			case <-comch.signal:
+				// skip passing error (it's already killed)
+			case comch.err <- err:
+			}
+		}
+	}()
+
+	connectWidgetOuput(widget, comch)
+
+	return nil
+}
+
+// sshJob is a command running in the shell of new SSH session (with environment and working directory of the job)
+type sshJob struct {
+	session *ssh.Session
+	ctx     context.Context
+	opts    *jobOptions
+	stdin   io.WriteCloser // input of the command (nil when the command doesn't get input)
+}
+
+// startSSHJob opens new SSH session, starts the shell with output into stdout and stderr and sends the command to it.
+// When input is true, stdin stays open for the input of the command. Context cancels the job (or times it out).
+func startSSHJob(ctx context.Context, cmd string, opts *jobOptions, stdout, stderr io.Writer, input bool) (*sshJob, error) {
+	if sshConn == nil {
+		return nil, errors.New("SSH connection not created! Adjust your configuration")
+	}
+
+	session, err := sshConn.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open new session: %v", err)
+	}
+	cmd = opts.remote(session, cmd)
+	session.Stdout = stdout
+	session.Stderr = stderr
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	// start shell
+	if err := session.Shell(); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("session shell: %s", err)
+	}
+
+	job := &sshJob{session: session, ctx: ctx, opts: opts}
+	if input {
+		// command is read by shell as a whole (with exit), so the rest of stdin is the input of the command
+		_, err = fmt.Fprintf(stdin, "{ %s\n}; exit\n", cmd)
+		job.stdin = stdin
+	} else {
+		// send command
+		_, err = fmt.Fprintf(stdin, "%s\n", cmd)
+		stdin.Close() // just one command
+	}
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	return job, nil
+}
+
+// stopOn closes the session (and the closers, like output pipes) when the signal is closed or the job is canceled
+func (j *sshJob) stopOn(signal <-chan struct{}, closers ...io.Closer) {
+	go func() {
+		select {
+		case <-signal:
+		case <-j.ctx.Done():
+		}
+		for _, c := range closers {
+			c.Close()
+		}
+		j.session.Close() // TODO: maybe instead of close, call Signal??
+		// session.Signal(ssh.SIGINT) // or maybe ssh.SIGTERM??
+	}()
+}
+
+// wait waits for the end of the command and returns its error (with exit status of the command or timeout)
+func (j *sshJob) wait() error {
+	defer j.session.Close()
+
+	err := j.session.Wait()
+	if err == nil {
+		return nil
+	}
+	efmt := fmt.Errorf("ssh: %v", err.Error())
+	// convert to ssh error if possible
+	if e, ok := err.(*ssh.ExitError); ok && e != nil {
+		efmt = &exitStatusError{e.ExitStatus(), fmt.Sprintf("ssh: %v", e.ExitStatus())}
+	}
+	return j.opts.timeoutError(j.ctx, efmt)
+}
@@ -1,242 +1,849 @@
-package zterm
-
-import (
-	"errors"
-	"fmt"
-	"io/ioutil"
-	"strconv"
-	"strings"
-	"time"
-
-	"github.com/awesome-gocui/gocui"
-	"github.com/spf13/viper"
-)
-
-// autocomplete map (command with subcommands/operands)
-var cmdAuto = map[string][]string{
-	"addview": {"joblog", "syslog", "messages"},
-	"attach":  {"joblog", "syslog", "messages"},
-	"code":    {},
-	"error":   {},
-	"exit":    {},
-	"help":    {},
-	"remote":  {},
-	"resize":  {"joblog", "syslog", "messages"},
-	"view":    {"joblog", "syslog", "messages"},
-	"savecfg": {},
-
-	"pwd":    {},
-	"whoami": {},
-	"which":  {},
-	"cd":     {},
-	"ls":     {"#list-dir"},
-}
-
-func commandExecute(wgm Widgeter, command string) error {
-	cmdParts := strings.Split(strings.TrimSpace(command), " ")
-
-	switch cmdParts[0] {
-	case "exit":
-		gui.Update(func(g *gocui.Gui) error {
-			return gocui.ErrQuit
-		})
-	case "help":
-		return errors.New("help: command not implemented yet")
-	case "error":
-		return errors.New("command failed")
-	case "addview":
-		if len(cmdParts) == 1 {
-			return errors.New("adview: requires view name to add it")
-		}
-
-		vname := cmdParts[1]
-		vmap, ok := config.Views[vname]
-		if ok {
-			return fmt.Errorf("view '%s' already exist", vname)
-		}
-		vmap = View{}
-		vmap.Size = 10
-		vmap.Position = viewLastPos + 1
-		viewLastPos = vmap.Position
-		if viewFirstPos < 0 {
-			viewFirstPos = vmap.Position
-		}
-		config.Views[vname] = vmap
-		viewMaxSize += vmap.Size
-		widget := NewWidgetStack(vname, vmap.Position, vmap.Size, "new view")
-		widgets = append(widgets, widget)
-		sortWidgetManager(widgets)
-		widget.Keybinds(gui)
-		// run layouts to sort the order (console on top)
-		widget.Layout(gui)
-		getConsoleWidget().Layout(gui)
-		return fmt.Errorf("view '%s' added", vname)
-	case "resize":
-		if len(cmdParts) == 1 {
-			return errors.New("resize: requires view name to resize it")
-		}
-
-		vname := cmdParts[1]
-		widget := getWidgetStack(vname)
-		if widget == nil {
-			return fmt.Errorf("resize: view '%s' doesn't exist", vname)
-		}
-		newsize := 1
-		if len(cmdParts) > 2 {
-			if is, err := strconv.Atoi(cmdParts[2]); err == nil {
-				newsize = is
-			}
-		}
-
-		// resize and adjust maxsize
-		widget.height += newsize
-		vmap := config.Views[vname]
-		viewMaxSize += widget.height - vmap.Size
-		vmap.Size = widget.height
-		config.Views[vname] = vmap // is this necessary ???
-		return fmt.Errorf("view '%s' resized", vname)
-	case "view":
-		if len(cmdParts) < 3 {
-			return errors.New(`missing arguments 
-usage: view <view-name> <config>
-
-config options: 
- hi-word   <word>    - highlight word
- hi-line   <word>    - highlight line which contains word
- hi-remove <word>    - remove highlight for specific word
- refresh   <number>  - set refresh interval to number`)
-		}
-
-		vname := cmdParts[1]
-		vconf := cmdParts[2]
-		widget := getWidgetStack(vname)
-		if widget == nil {
-			return fmt.Errorf("view: view '%s' doesn't exist", vname)
-		}
-		switch vconf {
-		case "hi-word":
-			fallthrough
-		case "hi-line":
-			if len(cmdParts) < 4 {
-				return fmt.Errorf("view: view %s needs a <word> parameter", vconf)
-			}
-			if widget.highlight == nil {
-				widget.highlight = make(map[string]bool)
-			}
-			if vconf == "hi-word" {
-				widget.highlight[cmdParts[3]] = false
-			} else {
-				widget.highlight[cmdParts[3]] = true
-			}
-		case "hi-remove":
-			if len(cmdParts) < 4 {
-				return fmt.Errorf("view: view %s needs a <word> parameter", vconf)
-			}
-			if widget.highlight != nil {
-				delete(widget.highlight, cmdParts[3])
-			}
-		default:
-			return fmt.Errorf("view: config option %s not implemented", vconf)
-		}
-		return fmt.Errorf("view %s configured", vname)
-	case "attach":
-		if len(cmdParts) < 3 {
-			return errors.New("missing arguments - usage: attach <view-name> <command>")
-		}
-
-		vname := cmdParts[1]
-		widget := getWidgetStack(vname)
-		if widget == nil {
-			return fmt.Errorf("attach: view '%s' doesn't exist", vname)
-		}
-		widget.StopFun()
-		widget.SetupFun(strings.Join(cmdParts[2:], " "))
-		widget.StartFun()
-		return fmt.Errorf("command attached to view '%s'", vname)
-	case "savecfg":
-		// update view configuration in viper
-		for k, v := range config.Views {
-			if ws := getWidgetStack(k); ws != nil {
-				// highlights
-				v.HiLine = []string{}
-				v.HiWord = []string{}
-				for hi, isline := range ws.highlight {
-					if isline {
-						v.HiLine = append(v.HiLine, hi)
-					} else {
-						v.HiWord = append(v.HiWord, hi)
-					}
-				}
-				// job
-				v.Job = ws.GetFunString()
-			}
-			viper.Set("views."+k, v)
-		}
-		cfgfile := viper.ConfigFileUsed()
-		data, _ := ioutil.ReadFile(cfgfile) // save for error
-		if err := viper.WriteConfig(); err != nil {
-			if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-				if err := viper.WriteConfigAs(".zterm.yml"); err != nil {
-					return fmt.Errorf("%v", err)
-				}
-			} else {
-				return fmt.Errorf("%v\noriginal config file: \n%v", err, string(data))
-			}
-		}
-		return fmt.Errorf("config file %v updated", cfgfile)
-	case "code":
-		// handle vscode command execution
-		if len(cmdParts) > 1 {
-			return cmdShell(wgm, command)
-		}
-		return cmdShell(wgm, "code --help")
-	case "vim":
-		// handle vim command execution
-		if len(cmdParts) > 1 {
-			return cmdVim(wgm, strings.Join(cmdParts[1:], " "))
-		}
-		return cmdShell(wgm, "vim --help")
-	case "rvim":
-		// handle vim command execution
-		if len(cmdParts) > 1 {
-			return cmdRVim(wgm, strings.Join(cmdParts[1:], " "))
-		}
-		return cmdShell(wgm, "vim --help")
-	case "remote":
-		if len(cmdParts) > 1 {
-			return cmdSSH(wgm, strings.Join(cmdParts[1:], " "))
-		}
-		return errors.New("remote: requires command to run on remote server")
-	case "fancy":
-		if len(cmdParts) > 1 {
-			fpipe := NewWidgetPipe(wgm)
-			if len(cmdParts) > 2 && cmdParts[1] == "remote" {
-				return cmdSSH(fpipe, strings.Join(cmdParts[2:], " "))
-			}
-			return cmdShell(fpipe, strings.Join(cmdParts[1:], " "))
-		}
-		return errors.New("fancy: requires command to run")
-	default:
-		// handle bash command execution
-		return cmdShell(wgm, command)
-	}
-
-	return nil
-}
-
-// simple function for testing widgets
-func cmdSyslogShell(widget Widgeter) error {
-	// handle bash command execution
-	return cmdSSH(widget, "zsyslog")
-}
-
-// simple function for testing widgets
-func cmdTestShell(widget Widgeter) error {
-	// fake error
-	if (time.Now().Second() % 30) < 10 {
-		return errors.New("WTF??? Eroooooooooooooooorrr... ")
-	}
-
-	// handle bash command execution
-	return cmdShell(widget, "./test.sh")
-}
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/spf13/viper"
+)
+
+func commandExecute(wgm Widgeter, command string) error {
+	steps, err := expandCommand(command)
+	if err != nil {
+		return err
+	}
+	switch len(steps) {
+	case 0:
+		return nil
+	case 1:
+		return runCommand(wgm, steps[0].cmd)
+	}
+	// chain is executed in background (commands wait for the previous one)
+	go runChain(wgm, steps, nil)
+	return nil
+}
+
+// runCommand executes one console command (without aliases and chaining).
+// Console commands are executed by their function from the registry, other commands by local shell.
+func runCommand(wgm Widgeter, command string) error {
+	cl, err := tokenize(strings.TrimSpace(command))
+	if err != nil {
+		return fmt.Errorf("parse: %v", err)
+	}
+	if len(cl.args) == 0 {
+		return nil
+	}
+	if cmd, r, err := parseRedirect(cl); err != nil {
+		return err
+	} else if r != nil {
+		return runRedirect(wgm, cmd, r)
+	}
+
+	if c, ok := cmdRegistry[cl.args[0]]; ok && c.run != nil {
+		if len(cl.args)-1 < c.minArgs() {
+			return c.usageError()
+		}
+		return c.run(wgm, cl)
+	}
+	if lexer, fcmd, ok := parseFancy(command); ok {
+		if len(fcmd) == 0 {
+			return errors.New("fancy: requires command to run")
+		}
+		fpipe := NewWidgetPipe(wgm, lexer, fcmd)
+		if strings.HasPrefix(fcmd, "remote ") {
+			return cmdRemote(fpipe, strings.TrimPrefix(fcmd, "remote "), nil)
+		}
+		return cmdShell(fpipe, fcmd)
+	}
+	// handle bash command execution
+	return cmdShell(wgm, command)
+}
+
+// runExit quits zterm
+func runExit(wgm Widgeter, cl *cmdLine) error {
+	gui.Update(func(g *gocui.Gui) error {
+		return gocui.ErrQuit
+	})
+	return nil
+}
+
+// runHelp displays help for commands
+func runHelp(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	help := commandsHelp()
+	if len(cmdParts) > 1 {
+		c, ok := cmdRegistry[cmdParts[1]]
+		if !ok {
+			return fmt.Errorf("help: command '%s' doesn't exist", cmdParts[1])
+		}
+		help = c.commandHelp()
+	}
+	if _, err := popupText(helpView, help); err != nil {
+		return fmt.Errorf("help: %v", err)
+	}
+	return nil
+}
+
+// runError fails (for testing)
+func runError(wgm Widgeter, cl *cmdLine) error {
+	return errors.New("command failed")
+}
+
+// runAddView adds new empty view to the layout
+func runAddView(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	vname := cmdParts[1]
+	if getWidgetStack(vname) != nil {
+		return fmt.Errorf("view '%s' already exist", vname)
+	}
+	layoutAddView(vname)
+	addView(vname)
+	return info("view '%s' added", vname)
+}
+
+// runSplit splits the view and puts new (or existing) view next to it
+func runSplit(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	vname, other := cmdParts[1], cmdParts[2]
+	if getWidgetStack(vname) == nil {
+		return fmt.Errorf("split: view '%s' doesn't exist", vname)
+	}
+	if ws := getWidgetStack(other); ws != nil && ws.workspace != currWorkspace.name {
+		return fmt.Errorf("split: view '%s' is in workspace '%s'", other, ws.workspace)
+	}
+	split := "columns"
+	if len(cmdParts) > 3 {
+		split = cmdParts[3]
+	}
+	if err := layoutSplit(vname, other, split); err != nil {
+		return err
+	}
+	if getWidgetStack(other) == nil {
+		addView(other)
+	}
+	return info("view '%s' split with '%s'", vname, other)
+}
+
+// runMove moves the view in the layout (by direction or to position)
+func runMove(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	if pos, err := strconv.Atoi(cmdParts[2]); err == nil {
+		if err := layoutMoveTo(cmdParts[1], pos); err != nil {
+			return err
+		}
+		return info("view '%s' moved to position %d", cmdParts[1], pos)
+	}
+	if err := layoutMove(cmdParts[1], cmdParts[2]); err != nil {
+		return err
+	}
+	return info("view '%s' moved %s", cmdParts[1], cmdParts[2])
+}
+
+// runSwap swaps positions of two views
+func runSwap(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	if err := layoutSwap(cmdParts[1], cmdParts[2]); err != nil {
+		return err
+	}
+	return info("views '%s' and '%s' swapped", cmdParts[1], cmdParts[2])
+}
+
+// runRmView removes the view
+func runRmView(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	if err := removeView(cmdParts[1]); err != nil {
+		return err
+	}
+	return info("view '%s' removed", cmdParts[1])
+}
+
+// runRename renames the view
+func runRename(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	if err := renameView(cmdParts[1], cmdParts[2]); err != nil {
+		return err
+	}
+	return info("view '%s' renamed to '%s'", cmdParts[1], cmdParts[2])
+}
+
+// runDetach stops and removes command from the view
+func runDetach(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	vname := cmdParts[1]
+	widget := getWidgetStack(vname)
+	if widget == nil {
+		return fmt.Errorf("detach: view '%s' doesn't exist", vname)
+	}
+	widget.StopFun()
+	widget.Disconnect()
+	widget.Fun = nil
+	widget.funStr = ""
+	return info("command detached from view '%s'", vname)
+}
+
+// runHideShow hides or displays the view (`hide` and `show` commands)
+func runHideShow(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	vname := cmdParts[1]
+	widget := getWidgetStack(vname)
+	if widget == nil {
+		return fmt.Errorf("%s: view '%s' doesn't exist", cmdParts[0], vname)
+	}
+	widget.Enabled = cmdParts[0] == "show"
+	if vmap, ok := config.Views[vname]; ok {
+		vmap.Hidden = !widget.Enabled
+		config.Views[vname] = vmap
+	}
+	// select other view if hidden one was selected
+	if wc := getConsoleWidget(); wc != nil && wc.lastView == vname && !widget.Enabled {
+		wc.lastView = nextWidgetStack(vname)
+	}
+	if cv := gui.CurrentView(); cv != nil && cv.Name() == vname && !widget.Enabled {
+		changeView(gui, cv)
+	}
+	if widget.Enabled {
+		return info("view '%s' displayed", vname)
+	}
+	return info("view '%s' hidden", vname)
+}
+
+// runResize changes size of the view
+func runResize(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	vname := cmdParts[1]
+	widget := getWidgetStack(vname)
+	if widget == nil {
+		return fmt.Errorf("resize: view '%s' doesn't exist", vname)
+	}
+	newsize := 1
+	if len(cmdParts) > 2 {
+		if is, err := strconv.Atoi(cmdParts[2]); err == nil {
+			newsize = is
+		}
+	}
+
+	// resize and adjust maxsize
+	widget.height += newsize
+	if n := layoutRoot.find(vname); n != nil && n.Weight > 0 {
+		n.Weight += newsize
+	}
+	vmap := config.Views[vname]
+	viewMaxSize += widget.height - vmap.Size
+	vmap.Size = widget.height
+	config.Views[vname] = vmap // is this necessary ???
+	return info("view '%s' resized", vname)
+}
+
+// runView configures the view (highlights, change detection, rendering)
+func runView(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	vname := cmdParts[1]
+	vconf := cmdParts[2]
+	widget := getWidgetStack(vname)
+	if widget == nil {
+		return fmt.Errorf("view: view '%s' doesn't exist", vname)
+	}
+	switch vconf {
+	case "hi-word":
+		fallthrough
+	case "hi-line":
+		if len(cmdParts) < 4 {
+			return fmt.Errorf("view: view %s needs a <word> parameter", vconf)
+		}
+		if widget.highlight == nil {
+			widget.highlight = make(map[string]bool)
+		}
+		if vconf == "hi-word" {
+			widget.highlight[cmdParts[3]] = false
+		} else {
+			widget.highlight[cmdParts[3]] = true
+		}
+	case "hi-remove":
+		if len(cmdParts) < 4 {
+			return fmt.Errorf("view: view %s needs a <word> parameter", vconf)
+		}
+		if widget.highlight != nil {
+			delete(widget.highlight, cmdParts[3])
+		}
+	case "watch-diff":
+		vmap := config.Views[vname]
+		vmap.WatchDiff = true
+		if len(cmdParts) > 3 {
+			if cmdParts[3] == "off" {
+				vmap.WatchDiff = false
+			} else if fade, err := strconv.Atoi(cmdParts[3]); err == nil {
+				vmap.WatchFade = fade
+			} else if cmdParts[3] != "on" {
+				return fmt.Errorf("view: view %s needs on, off or <fade> number parameter", vconf)
+			}
+		}
+		if vmap.WatchDiff {
+			widget.diff = newWatchDiff(vmap.WatchFade)
+		} else {
+			widget.diff = nil
+		}
+		config.Views[vname] = vmap
+	case "strip-ansi":
+		vmap := config.Views[vname]
+		vmap.StripAnsi = len(cmdParts) < 4 || cmdParts[3] != "off"
+		widget.stripAnsi = vmap.StripAnsi
+		config.Views[vname] = vmap
+	case "render":
+		if len(cmdParts) < 4 {
+			return fmt.Errorf("view: view %s needs text, table or graph parameter", vconf)
+		}
+		vmap := config.Views[vname]
+		vmap.Render = cmdParts[3]
+		if err := widget.SetRender(vmap); err != nil {
+			return fmt.Errorf("view: %v", err)
+		}
+		config.Views[vname] = vmap
+		// restart job to render output in new way
+		widget.StopFun()
+		widget.SetupFun(widget.GetFunString())
+	case "graph-regex", "graph-json", "graph-style":
+		if len(cmdParts) < 4 {
+			return fmt.Errorf("view: view %s needs a parameter", vconf)
+		}
+		vmap := config.Views[vname]
+		arg := strings.Join(cmdParts[3:], " ")
+		switch vconf {
+		case "graph-regex":
+			vmap.Graph.Regex, vmap.Graph.JSON = arg, ""
+		case "graph-json":
+			vmap.Graph.JSON, vmap.Graph.Regex = arg, ""
+		case "graph-style":
+			vmap.Graph.Style = arg
+		}
+		if vmap.Render == "graph" {
+			if err := widget.SetRender(vmap); err != nil {
+				return fmt.Errorf("view: %v", err)
+			}
+			widget.StopFun()
+			widget.SetupFun(widget.GetFunString())
+		}
+		config.Views[vname] = vmap
+	case "table-sort", "table-hide", "table-show", "table-format":
+		if len(cmdParts) < 4 {
+			return fmt.Errorf("view: view %s needs a <column> parameter", vconf)
+		}
+		vmap := config.Views[vname]
+		switch vconf {
+		case "table-sort":
+			vmap.Table.Sort = cmdParts[3]
+		case "table-hide":
+			vmap.Table.Hide = append(vmap.Table.Hide, cmdParts[3])
+		case "table-show":
+			hide := []string{}
+			for _, h := range vmap.Table.Hide {
+				if !strings.EqualFold(h, cmdParts[3]) {
+					hide = append(hide, h)
+				}
+			}
+			vmap.Table.Hide = hide
+		case "table-format":
+			vmap.Table.Format = cmdParts[3]
+		}
+		config.Views[vname] = vmap
+		if wt, ok := widget.render.(*WidgetTable); ok {
+			wt.Update(vmap.Table)
+		}
+	case "refresh", "schedule":
+		if len(cmdParts) < 4 {
+			return fmt.Errorf("view: view %s needs a parameter", vconf)
+		}
+		vmap := config.Views[vname]
+		arg := strings.Join(cmdParts[3:], " ")
+		if arg == "off" || arg == "default" {
+			arg = ""
+		}
+		if vconf == "refresh" {
+			vmap.Refresh = arg
+		} else {
+			vmap.Schedule = arg
+		}
+		if err := widget.SetRefresh(vmap); err != nil {
+			return fmt.Errorf("view: %v", err)
+		}
+		config.Views[vname] = vmap
+		if vconf == "refresh" && !widget.paused {
+			// start with new interval
+			widget.StartFun()
+		}
+	case "cwd", "shell", "timeout", "env":
+		if len(cmdParts) < 4 {
+			return fmt.Errorf("view: view %s needs a parameter", vconf)
+		}
+		vmap := config.Views[vname]
+		arg := cmdParts[3]
+		if arg == "default" {
+			arg = ""
+		}
+		switch vconf {
+		case "cwd":
+			vmap.Cwd = arg
+		case "shell":
+			vmap.Shell = arg
+		case "timeout":
+			vmap.Timeout = arg
+		case "env":
+			// `name=value` sets variable, `name=` removes it
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok || name == "" {
+				return errors.New("view: env needs <name>=<value> parameter")
+			}
+			env := []string{}
+			for _, kv := range vmap.Env {
+				if !strings.HasPrefix(kv, name+"=") {
+					env = append(env, kv)
+				}
+			}
+			if value != "" {
+				env = append(env, arg)
+			}
+			vmap.Env = env
+		}
+		if err := widget.SetJob(vmap); err != nil {
+			return fmt.Errorf("view: %v", err)
+		}
+		config.Views[vname] = vmap
+		// restart job with new options
+		if widget.Fun != nil {
+			widget.StopFun()
+			widget.SetupFun(widget.GetFunString())
+		}
+	case "alert", "alert-remove":
+		if len(cmdParts) < 4 {
+			return fmt.Errorf("view: view %s needs a <regex> parameter", vconf)
+		}
+		vmap := config.Views[vname]
+		rules := []AlertRule{}
+		for _, ar := range vmap.Alerts {
+			if ar.Regex != cmdParts[3] && ar.Name != cmdParts[3] {
+				rules = append(rules, ar)
+			}
+		}
+		if vconf == "alert" {
+			rules = append(rules, AlertRule{Regex: cmdParts[3]})
+		}
+		vmap.Alerts = rules
+		if err := widget.SetAlerts(vmap); err != nil {
+			return fmt.Errorf("view: %v", err)
+		}
+		config.Views[vname] = vmap
+	case "paused":
+		if widget.Fun == nil {
+			return fmt.Errorf("view: view '%s' has no attached command", vname)
+		}
+		widget.SetPaused(len(cmdParts) < 4 || cmdParts[3] != "off")
+	default:
+		return fmt.Errorf("view: config option %s not implemented", vconf)
+	}
+	return info("view %s configured", vname)
+}
+
+// runAttach runs command periodically in the view
+func runAttach(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	vname := cmdParts[1]
+	widget := getWidgetStack(vname)
+	if widget == nil {
+		return fmt.Errorf("attach: view '%s' doesn't exist", vname)
+	}
+	widget.StopFun()
+	// attached command runs (even if the view was paused), it's started by SetupFun
+	widget.markPaused(false)
+	widget.SetupFun(cl.shellRest(2))
+	return info("command attached to view '%s'", vname)
+}
+
+// runWorkspace lists workspaces or switches to workspace
+func runWorkspace(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	if len(cmdParts) == 1 {
+		// list workspaces
+		names := []string{}
+		for _, wsp := range workspaces {
+			names = append(names, wsp.name)
+		}
+		return info("workspaces: %s (current: %s)", strings.Join(names, ", "), currWorkspace.name)
+	}
+	if err := switchWorkspace(gui, cmdParts[1]); err != nil {
+		return err
+	}
+	return info("workspace '%s' selected", cmdParts[1])
+}
+
+// runAlias lists aliases, displays or defines alias
+func runAlias(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	if len(cmdParts) == 1 {
+		// list aliases
+		lines := []string{}
+		for _, name := range aliasNames() {
+			if alias, ok := config.Aliases[name]; ok {
+				lines = append(lines, fmt.Sprintf("alias %s=%s", name, quoteArg(alias)))
+			}
+		}
+		return info("%s", strings.Join(lines, "\n"))
+	}
+	for _, def := range cmdParts[1:] {
+		name, value, ok := strings.Cut(def, "=")
+		if !ok {
+			alias, ok := config.Aliases[name]
+			if !ok {
+				return fmt.Errorf("alias: '%s' not found", name)
+			}
+			return info("alias %s=%s", name, quoteArg(alias))
+		}
+		if config.Aliases == nil {
+			config.Aliases = map[string]string{}
+		}
+		config.Aliases[name] = value
+	}
+	viper.Set("aliases", config.Aliases)
+	return info("alias defined")
+}
+
+// runUnalias removes alias
+func runUnalias(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	if _, ok := config.Aliases[cmdParts[1]]; !ok {
+		return fmt.Errorf("unalias: '%s' not found", cmdParts[1])
+	}
+	delete(config.Aliases, cmdParts[1])
+	viper.Set("aliases", config.Aliases)
+	return info("alias '%s' removed", cmdParts[1])
+}
+
+// runMacro lists macros or displays macro
+func runMacro(wgm Widgeter, cl *cmdLine) error {
+	cmdParts := cl.args
+	if len(cmdParts) == 1 {
+		names := []string{}
+		for _, name := range aliasNames() {
+			if _, ok := config.Macros[name]; ok {
+				names = append(names, name)
+			}
+		}
+		return info("macros: %s", strings.Join(names, ", "))
+	}
+	macro, ok := config.Macros[cmdParts[1]]
+	if !ok {
+		return fmt.Errorf("macro: '%s' not found", cmdParts[1])
+	}
+	return info("macro %s:\n %s", cmdParts[1], strings.Join(macro, "\n "))
+}
+
+// runPin attaches the last console command to the view
+func runPin(wgm Widgeter, cl *cmdLine) error {
+	return pinCommand(cl.args[1:])
+}
+
+// runSource executes console commands from the file
+func runSource(wgm Widgeter, cl *cmdLine) error {
+	file, keepGoing, err := parseSource(cl.args[1:])
+	if err != nil {
+		return err
+	}
+	return cmdSource(wgm, file, keepGoing)
+}
+
+// runStatus lists job status of views
+func runStatus(wgm Widgeter, cl *cmdLine) error {
+	table, err := jobStatusTable(cl.args[1:])
+	if err != nil {
+		return err
+	}
+	return info("%s", table)
+}
+
+// runSaveCfg saves views (with their highlights and jobs), layout and workspaces into config file
+func runSaveCfg(wgm Widgeter, cl *cmdLine) error {
+	currWorkspace.views = config.Views
+	currWorkspace.layout = layoutRoot
+	for _, wsp := range workspaces {
+		// update view configuration
+		for k, v := range wsp.views {
+			if ws := getWidgetStack(k); ws != nil {
+				// highlights
+				v.HiLine = []string{}
+				v.HiWord = []string{}
+				for hi, isline := range ws.highlight {
+					if isline {
+						v.HiLine = append(v.HiLine, hi)
+					} else {
+						v.HiWord = append(v.HiWord, hi)
+					}
+				}
+				// job
+				v.Job = ws.GetFunString()
+			}
+			wsp.views[k] = v
+		}
+	}
+	cfgfile, err := saveConfig()
+	if err != nil {
+		return err
+	}
+	return info("config file %v updated", cfgfile)
+}
+
+// runCode opens file in vscode
+func runCode(wgm Widgeter, cl *cmdLine) error {
+	// handle vscode command execution
+	if len(cl.args) > 1 {
+		return cmdShell(wgm, cl.raw)
+	}
+	return cmdShell(wgm, "code --help")
+}
+
+// runVim edits local file in vim
+func runVim(wgm Widgeter, cl *cmdLine) error {
+	// handle vim command execution
+	return cmdVim(wgm, cl.rest(1))
+}
+
+// runRVim edits remote file or dataset in vim
+func runRVim(wgm Widgeter, cl *cmdLine) error {
+	// handle vim command execution
+	return cmdRVim(wgm, cl.rest(1))
+}
+
+// runRemote runs command on remote server
+func runRemote(wgm Widgeter, cl *cmdLine) error {
+	if rcmd, stages, ok := splitPipeline(cl.rest(1)); ok {
+		return cmdPipeline(wgm, rcmd, stages, nil)
+	}
+	return cmdSSH(wgm, cl.shellRest(1))
+}
+
+// simple function for testing widgets
+func cmdSyslogShell(widget Widgeter) error {
+	// handle bash command execution
+	return cmdSSH(widget, "zsyslog")
+}
+
+// simple function for testing widgets
+func cmdTestShell(widget Widgeter) error {
+	// fake error
+	if (time.Now().Second() % 30) < 10 {
+		return errors.New("WTF??? Eroooooooooooooooorrr... ")
+	}
+
+	// handle bash command execution
+	return cmdShell(widget, "./test.sh")
+}
+
+// addView creates new view with widget stack (view has to be in the layout already)
+func addView(vname string) {
+	vmap := View{}
+	vmap.Size = 10
+	config.Views[vname] = vmap
+	viewMaxSize += vmap.Size
+	widget := NewWidgetStack(vname, 0, vmap.Size, "new view")
+	widget.workspace = currWorkspace.name
+	widgets = append(widgets, widget)
+	layoutRenumber()
+	widget.Keybinds(gui)
+	// run layouts to sort the order (console on top)
+	widget.Layout(gui)
+	getConsoleWidget().Layout(gui)
+}
+
+// removeView stops job of the view and removes it from widgets, layout and configuration
+func removeView(vname string) error {
+	widget := getWidgetStack(vname)
+	if widget == nil {
+		return fmt.Errorf("rmview: view '%s' doesn't exist", vname)
+	}
+	// stop job and remove from GUI
+	widget.StopFun()
+	widget.Disconnect()
+	widget.Enabled = false
+	if wt, ok := widget.render.(*WidgetTable); ok {
+		wt.Enabled = false
+		wt.Layout(gui)
+	}
+	deleteKeybindings(gui, vname)
+	gui.DeleteView(vname)
+	for i, w := range widgets {
+		if w == widget {
+			widgets = append(widgets[:i], widgets[i+1:]...)
+			break
+		}
+	}
+
+	// remove from configuration and layout of its workspace
+	wsp := getWorkspace(widget.workspace)
+	root := wsp.layout
+	if wsp == currWorkspace {
+		root = layoutRoot
+	}
+	if root.View == vname {
+		root = &LayoutNode{Split: "rows"}
+	}
+	root.remove(vname)
+	delete(wsp.views, vname)
+	if wsp == currWorkspace {
+		layoutRoot = root
+		layoutRenumber()
+	} else {
+		wsp.layout = root
+	}
+
+	// clean references
+	if zoomedView == vname {
+		zoomedView = ""
+	}
+	if sel != nil && sel.view == vname {
+		endSelection(gui)
+	}
+	if wc := getConsoleWidget(); wc != nil && wc.lastView == vname {
+		wc.lastView = ""
+	}
+	if cv := gui.CurrentView(); cv == nil || cv.Name() == vname {
+		setDefaultView(gui)
+	}
+	return nil
+}
+
+// renameView changes name of the view (in widgets, layout and configuration)
+func renameView(vname string, newname string) error {
+	widget := getWidgetStack(vname)
+	if widget == nil {
+		return fmt.Errorf("rename: view '%s' doesn't exist", vname)
+	}
+	if getWidgetManager(newname) != nil {
+		return fmt.Errorf("rename: view '%s' already exist", newname)
+	}
+	selected := false
+	if cv := gui.CurrentView(); cv != nil && cv.Name() == vname {
+		selected = true
+	}
+	// view is created again with new name (with its keybinds)
+	deleteKeybindings(gui, vname)
+	gui.DeleteView(vname)
+	widget.gview = nil
+	widget.name = newname
+	if wt, ok := widget.render.(*WidgetTable); ok {
+		wt.Enabled = false
+		wt.Layout(gui)
+		wt.name = newname + "-header"
+		wt.Enabled = true
+	}
+	widget.Keybinds(gui)
+
+	// configuration and layout
+	wsp := getWorkspace(widget.workspace)
+	wsp.views[newname] = wsp.views[vname]
+	delete(wsp.views, vname)
+	root := wsp.layout
+	if wsp == currWorkspace {
+		root = layoutRoot
+	}
+	if n := root.find(vname); n != nil {
+		n.View = newname
+	}
+	if wsp == currWorkspace {
+		layoutRenumber()
+		arrangeLayout(gui)
+	}
+
+	// references
+	if zoomedView == vname {
+		zoomedView = newname
+	}
+	if sel != nil && sel.view == vname {
+		endSelection(gui)
+	}
+	if wc := getConsoleWidget(); wc != nil && wc.lastView == vname {
+		wc.lastView = newname
+	}
+	widget.Layout(gui)
+	if selected {
+		gui.SetCurrentView(newname)
+	}
+	return nil
+}
+
+// saveConfig writes configuration owned by zterm (views, layouts, workspaces, aliases and history) into config file.
+// Other settings are kept as they are in the config file (values from flags and defaults are not written).
+// Views are created from scratch, so removed views are not kept from the original config file.
+// Returns name of the written config file.
+func saveConfig() (string, error) {
+	file := viper.New()
+	cfgfile := viper.ConfigFileUsed()
+	var data []byte
+	if cfgfile != "" {
+		file.SetConfigFile(cfgfile)
+		data, _ = ioutil.ReadFile(cfgfile) // save for error
+		if err := file.ReadInConfig(); err != nil && len(data) > 0 {
+			return cfgfile, fmt.Errorf("%v\noriginal config file: \n%v", err, string(data))
+		}
+	}
+	settings := file.AllSettings()
+	for _, key := range []string{"views", "layout", "workspaces", "aliases", "history"} {
+		delete(settings, key)
+	}
+
+	others := map[string]interface{}{}
+	for _, wsp := range workspaces {
+		// layout (only if it's not just views in rows)
+		var layout *LayoutNode
+		if !wsp.layout.isDefault() {
+			layout = wsp.layout
+		}
+		if wsp.name != mainWorkspace {
+			others[wsp.name] = Workspace{Inactive: wsp.inactive, Views: wsp.views, Layout: layout}
+			continue
+		}
+		// main workspace in `views` and `layout`
+		views := map[string]interface{}{}
+		for k, v := range wsp.views {
+			views[k] = v
+		}
+		settings["views"] = views
+		if layout != nil {
+			settings["layout"] = layout
+		}
+		if wsp.inactive != "" {
+			others[wsp.name] = Workspace{Inactive: wsp.inactive}
+		}
+	}
+	if len(others) > 0 {
+		settings["workspaces"] = others
+	}
+	if len(config.Aliases) > 0 {
+		settings["aliases"] = config.Aliases
+	}
+	if config.History != (History{}) {
+		settings["history"] = config.History
+	}
+
+	cfg := viper.New()
+	if err := cfg.MergeConfigMap(settings); err != nil {
+		return cfgfile, err
+	}
+	if cfgfile != "" {
+		cfg.SetConfigFile(cfgfile)
+		if filepath.Ext(cfgfile) == "" {
+			cfg.SetConfigType("yaml")
+		}
+	}
+	if err := cfg.WriteConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			// no config file yet
+			cfgfile = ".zterm.yml"
+			return cfgfile, cfg.WriteConfigAs(cfgfile)
+		}
+		return cfgfile, fmt.Errorf("%v\noriginal config file: \n%v", err, string(data))
+	}
+	return cfgfile, nil
+}
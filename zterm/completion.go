@@ -0,0 +1,324 @@
+package zterm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// completion is a menu with candidates for the argument (Tab and Shift+Tab cycle thru them)
+type completion struct {
+	prefix     string // command line before the completed argument
+	candidates []string
+	idx        int    // selected candidate (-1 when nothing is selected yet)
+	line       string // command line after last completion (menu is closed when the line is changed)
+}
+
+const (
+	compView       = "completion-menu"
+	remoteTimeout  = 3 * time.Second  // max time to wait for remote candidates
+	remoteCacheTTL = 30 * time.Second // remote candidates are cached for this time
+)
+
+var compMenu *completion
+
+// remoteListing is output of the remote command listing candidates (like `ls` or `LISTCAT`)
+type remoteListing struct {
+	out     string
+	err     error
+	pending bool // command is running in background
+	time    time.Time
+}
+
+// remoteListings are cached outputs of remote commands by the command (used only in GUI thread)
+var remoteListings = map[string]*remoteListing{}
+
+// autoComplete completes the argument under cursor, or displays menu when there are more candidates
+func autoComplete(g *gocui.Gui, v *gocui.View) error {
+	return cycleCompletion(1)
+}
+
+// autoCompleteBack selects previous candidate in the completion menu
+func autoCompleteBack(g *gocui.Gui, v *gocui.View) error {
+	return cycleCompletion(-1)
+}
+
+func cycleCompletion(step int) error {
+	wc := getConsoleWidget()
+	if wc == nil {
+		return nil
+	}
+	line := wc.editor.String()
+	// menu is displayed, select next (or previous) candidate
+	if compMenu != nil && compMenu.line == line {
+		n := len(compMenu.candidates)
+		compMenu.idx = (compMenu.idx + step + n) % n
+		compMenu.line = compMenu.prefix + insertArg(compMenu.candidates[compMenu.idx])
+		wc.editor.setText(compMenu.line)
+		return nil
+	}
+
+	compMenu = nil
+	cl := tokenizePartial(line)
+	final := completeCommand(cl.args)
+	prefix := line[:cl.offs[len(cl.offs)-1]]
+	switch {
+	case len(final) == 1:
+		finalcmd := prefix + insertArg(final[0])
+		if !strings.HasSuffix(final[0], "/") {
+			finalcmd += " "
+		}
+		wc.editor.setText(finalcmd)
+	case len(final) > 1:
+		// complete common part (only if it doesn't need quotes) and display menu
+		if common := commonPrefix(final); common != "" && quoteArg(common) == common {
+			line = prefix + common
+			wc.editor.setText(line)
+		}
+		compMenu = &completion{prefix: prefix, candidates: final, idx: -1, line: line}
+	}
+	return nil
+}
+
+// insertArg returns candidate as it's inserted into command line (quoted if needed, dataset names are kept as they are)
+func insertArg(c string) string {
+	if isDsn(c) {
+		return c
+	}
+	return quoteArg(c)
+}
+
+// commonPrefix returns common prefix of all the candidates (compared by runes, so multi-byte characters are not split)
+func commonPrefix(list []string) string {
+	common := []rune(list[0])
+	for _, c := range list[1:] {
+		runes := []rune(c)
+		n := 0
+		for n < len(common) && n < len(runes) && common[n] == runes[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return string(common)
+}
+
+// layoutCompletion displays completion menu on the line above console prompt (selected candidate is highlighted)
+func layoutCompletion(g *gocui.Gui, x0, y, x1 int) error {
+	if compMenu == nil {
+		g.DeleteView(compView) // if doesn't exist, don't care
+		return nil
+	}
+	v, err := g.SetView(compView, x0, y-1, x1, y+1, 0)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return fmt.Errorf("view %v: %v", compView, err)
+	}
+	v.Frame = false
+	v.Clear()
+	// start the menu from the selected candidate when it doesn't fit
+	width := x1 - x0
+	labels := []string{}
+	for _, c := range compMenu.candidates {
+		labels = append(labels, menuLabel(c))
+	}
+	first, size := 0, 0
+	for i := 0; i <= compMenu.idx; i++ {
+		size += len([]rune(labels[i])) + 2
+		for size > width && first < i {
+			size -= len([]rune(labels[first])) + 2
+			first++
+		}
+	}
+	var sb strings.Builder
+	for i := first; i < len(labels); i++ {
+		if i == compMenu.idx {
+			sb.WriteString("\x1b[7m" + labels[i] + "\x1b[0m  ")
+		} else {
+			sb.WriteString(labels[i] + "  ")
+		}
+	}
+	fmt.Fprint(v, sb.String())
+	g.SetViewOnTop(compView)
+	return nil
+}
+
+// menuLabel returns label of the candidate displayed in the menu (only last part of the path)
+func menuLabel(c string) string {
+	if isDsn(c) {
+		return c
+	}
+	trimmed := strings.TrimSuffix(c, "/")
+	if i := strings.LastIndex(trimmed, "/"); i >= 0 {
+		return c[i+1:]
+	}
+	return c
+}
+
+// completeLocalPaths returns local files and directories for the last argument (directories end with /)
+func completeLocalPaths(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	typed := args[len(args)-1]
+	dir, _ := filepath.Split(typed)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	} else if strings.HasPrefix(readDir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			readDir = filepath.Join(home, readDir[2:])
+		}
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+	_, base := filepath.Split(typed)
+	paths := []string{}
+	for _, e := range entries {
+		// hidden files only when asked for
+		if strings.HasPrefix(e.Name(), ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		p := dir + e.Name()
+		if e.IsDir() {
+			p += "/"
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+var (
+	listcatRe = regexp.MustCompile(`^\s*(?:NONVSAM|CLUSTER|GDG|ALIAS|PAGESPACE)\s+-+\s+(\S+)`)
+	// dataset name (qualifiers of 1-8 characters separated by dots)
+	dsnNameRe = regexp.MustCompile(`^[A-Z#@$][A-Z0-9#@$-]{0,7}(\.[A-Z#@$][A-Z0-9#@$-]{0,7})*$`)
+)
+
+// completeRemotePaths returns USS files and directories or dataset names (and members) from remote server for the last argument
+func completeRemotePaths(args []string) []string {
+	if len(args) == 0 || sshConn == nil {
+		return nil
+	}
+	typed := args[len(args)-1]
+	if !isDsn(typed) {
+		dir := typed[:strings.LastIndex(typed, "/")+1]
+		out, ok := cachedRemoteOutput("ls -1ap " + remoteDir(dir))
+		if !ok {
+			return nil
+		}
+		paths := []string{}
+		for _, name := range strings.Split(out, "\n") {
+			if name == "" || name == "./" || name == "../" {
+				continue
+			}
+			paths = append(paths, dir+name)
+		}
+		return paths
+	}
+
+	// dataset name (like //'USER.JCL' or //'USER.JCL(MEMBER)')
+	dsn := strings.ToUpper(strings.Trim(strings.TrimPrefix(strings.Trim(typed, "\""), "//"), "'"))
+	if i := strings.Index(dsn, "("); i >= 0 {
+		ds := dsn[:i]
+		if !dsnNameRe.MatchString(ds) {
+			// it's passed to TSO command, so only valid names are listed
+			return nil
+		}
+		out, ok := cachedRemoteOutput(fmt.Sprintf(`tsocmd "LISTDS '%s' MEMBERS"`, ds))
+		if !ok {
+			return nil
+		}
+		members := []string{}
+		inMembers := false
+		for _, line := range strings.Split(out, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "--MEMBERS--") {
+				inMembers = true
+			} else if inMembers && line != "" {
+				members = append(members, fmt.Sprintf("//'%s(%s)'", ds, strings.Fields(line)[0]))
+			}
+		}
+		return members
+	}
+	level := dsn
+	if i := strings.LastIndex(dsn, "."); i >= 0 {
+		level = dsn[:i]
+	}
+	if !dsnNameRe.MatchString(level) {
+		// it's passed to TSO command, so only valid names are listed
+		return nil
+	}
+	out, ok := cachedRemoteOutput(fmt.Sprintf(`tsocmd "LISTCAT LEVEL(%s)"`, level))
+	if !ok {
+		return nil
+	}
+	datasets := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if m := listcatRe.FindStringSubmatch(line); m != nil {
+			datasets = append(datasets, fmt.Sprintf("//'%s'", m[1]))
+		}
+	}
+	return datasets
+}
+
+// completeRemoteArgs returns remote paths for arguments of remote command (not for the command itself)
+func completeRemoteArgs(args []string) []string {
+	if len(args) < 2 {
+		return nil
+	}
+	return completeRemotePaths(args)
+}
+
+// remoteDir returns directory quoted for remote shell (home directory is expanded by the shell)
+func remoteDir(dir string) string {
+	switch {
+	case dir == "":
+		return "."
+	case dir == "~/":
+		return "~/"
+	case strings.HasPrefix(dir, "~/"):
+		return "~/" + quoteArg(dir[2:])
+	}
+	return quoteArg(dir)
+}
+
+// remoteOutput runs command on the remote server and returns its output (waits max remoteTimeout)
+func remoteOutput(cmd string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	out, err := sshConn.RunContext(ctx, cmd)
+	return string(out), err
+}
+
+// cachedRemoteOutput returns cached output of the remote command. When it isn't cached (or it's too old), command runs
+// in background (GUI doesn't wait for SSH) and completion is done again when the output is received, if the console
+// line wasn't changed meanwhile.
+func cachedRemoteOutput(cmd string) (string, bool) {
+	if l, ok := remoteListings[cmd]; ok && (l.pending || time.Since(l.time) < remoteCacheTTL) {
+		return l.out, !l.pending && l.err == nil
+	}
+	l := &remoteListing{pending: true}
+	remoteListings[cmd] = l
+	line := ""
+	if wc := getConsoleWidget(); wc != nil {
+		line = wc.editor.String()
+	}
+	go func() {
+		out, err := remoteOutput(cmd)
+		gui.Update(func(g *gocui.Gui) error {
+			l.out, l.err, l.pending, l.time = out, err, false, time.Now()
+			if wc := getConsoleWidget(); wc != nil && err == nil && compMenu == nil && wc.editor.String() == line {
+				return cycleCompletion(1)
+			}
+			return nil
+		})
+	}()
+	return "", false
+}
@@ -0,0 +1,323 @@
+package zterm
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/mattn/go-runewidth"
+)
+
+// lineEditor is a readline-like editor of the input text (which can have more lines).
+// It keeps the text and the cursor position in runes, so it doesn't depend on the view which displays it.
+type lineEditor struct {
+	text      []rune
+	pos       int    // cursor position (index into text)
+	killed    []rune // last killed text (inserted back by Ctrl+Y)
+	killing   bool   // last action was kill (next kill is joined with the killed text)
+	overwrite bool
+}
+
+// editorKeys describes keys handled by the line editor (displayed in help)
+var editorKeys = [][2]string{
+	{"Left, Right, Ctrl+B, Ctrl+F", "move cursor by character"},
+	{"Alt+B, Alt+F", "move cursor by word"},
+	{"Home, End, Ctrl+A, Ctrl+E", "move cursor to start or end of the line"},
+	{"Backspace, Delete", "delete character before or under cursor"},
+	{"Ctrl+W, Alt+D", "delete word before or after cursor"},
+	{"Ctrl+U, Ctrl+K", "delete text before or after cursor"},
+	{"Ctrl+Y", "insert last deleted text"},
+	{"Alt+Enter", "insert new line"},
+	{"Insert", "switch insert and overwrite mode"},
+}
+
+// setText replaces text of the editor and moves cursor to the end
+func (e *lineEditor) setText(text string) {
+	e.text = []rune(text)
+	e.pos = len(e.text)
+	e.killing = false
+}
+
+// String returns text of the editor
+func (e *lineEditor) String() string {
+	return string(e.text)
+}
+
+// insert writes text at the cursor (in overwrite mode it replaces characters up to the end of line)
+func (e *lineEditor) insert(rs ...rune) {
+	for _, r := range rs {
+		if e.overwrite && r != '\n' && e.pos < len(e.text) && e.text[e.pos] != '\n' {
+			e.text[e.pos] = r
+		} else {
+			e.text = append(e.text[:e.pos], append([]rune{r}, e.text[e.pos:]...)...)
+		}
+		e.pos++
+	}
+}
+
+// remove deletes text between positions and returns it
+func (e *lineEditor) remove(from, to int) []rune {
+	removed := append([]rune{}, e.text[from:to]...)
+	e.text = append(e.text[:from], e.text[to:]...)
+	e.pos = from
+	return removed
+}
+
+// kill deletes text between positions and keeps it for yank (consecutive kills are joined)
+func (e *lineEditor) kill(from, to int) {
+	backward := to == e.pos
+	removed := e.remove(from, to)
+	switch {
+	case !e.killing:
+		e.killed = removed
+	case backward:
+		e.killed = append(removed, e.killed...)
+	default:
+		e.killed = append(e.killed, removed...)
+	}
+	e.killing = true
+}
+
+// yank inserts last killed text at the cursor
+func (e *lineEditor) yank() {
+	e.insert(e.killed...)
+}
+
+func (e *lineEditor) backspace() {
+	if e.pos > 0 {
+		e.remove(e.pos-1, e.pos)
+	}
+}
+
+func (e *lineEditor) deleteChar() {
+	if e.pos < len(e.text) {
+		e.remove(e.pos, e.pos+1)
+	}
+}
+
+func (e *lineEditor) left() {
+	if e.pos > 0 {
+		e.pos--
+	}
+}
+
+func (e *lineEditor) right() {
+	if e.pos < len(e.text) {
+		e.pos++
+	}
+}
+
+// lineStart returns position of the start of the line with cursor
+func (e *lineEditor) lineStart() int {
+	i := e.pos
+	for i > 0 && e.text[i-1] != '\n' {
+		i--
+	}
+	return i
+}
+
+// lineEnd returns position of the end of the line with cursor
+func (e *lineEditor) lineEnd() int {
+	i := e.pos
+	for i < len(e.text) && e.text[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+// wordStart returns position of the start of the word before cursor (words are letters and digits)
+func (e *lineEditor) wordStart() int {
+	i := e.pos
+	for i > 0 && !isWordRune(e.text[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(e.text[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd returns position of the end of the word after cursor (words are letters and digits)
+func (e *lineEditor) wordEnd() int {
+	i := e.pos
+	for i < len(e.text) && !isWordRune(e.text[i]) {
+		i++
+	}
+	for i < len(e.text) && isWordRune(e.text[i]) {
+		i++
+	}
+	return i
+}
+
+// argStart returns position of the start of the whitespace separated argument before cursor (for Ctrl+W)
+func (e *lineEditor) argStart() int {
+	i := e.pos
+	for i > 0 && unicode.IsSpace(e.text[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(e.text[i-1]) {
+		i--
+	}
+	return i
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// killToEnd deletes text from cursor to the end of line (at the end of line it joins the next line)
+func (e *lineEditor) killToEnd() {
+	end := e.lineEnd()
+	if end == e.pos && end < len(e.text) {
+		end++
+	}
+	e.kill(e.pos, end)
+}
+
+// moveLine moves cursor to the previous (-1) or next (1) line and keeps its column.
+// It returns false when there is no such line.
+func (e *lineEditor) moveLine(dir int) bool {
+	start := e.lineStart()
+	col := runewidth.StringWidth(string(e.text[start:e.pos]))
+	if dir < 0 {
+		if start == 0 {
+			return false
+		}
+		e.pos = start - 1
+		e.pos = e.lineStart()
+	} else {
+		end := e.lineEnd()
+		if end == len(e.text) {
+			return false
+		}
+		e.pos = end + 1
+	}
+	// find the same column (or the end of shorter line)
+	end := e.lineEnd()
+	for w := 0; e.pos < end; e.pos++ {
+		w += runewidth.RuneWidth(e.text[e.pos])
+		if w > col {
+			break
+		}
+	}
+	return true
+}
+
+// continued checks if the text ends with backslash (line continues on the next line)
+func (e *lineEditor) continued() bool {
+	n := 0
+	for i := len(e.text) - 1; i >= 0 && e.text[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1 && e.pos == len(e.text)
+}
+
+// handleKey edits the text by the key and returns false when the key isn't handled by editor
+func (e *lineEditor) handleKey(key gocui.Key, ch rune, mod gocui.Modifier) bool {
+	killing := e.killing
+	e.killing = false
+	switch {
+	case ch != 0 && mod == gocui.ModNone:
+		e.insert(ch)
+	case ch != 0 && mod == gocui.ModAlt:
+		switch ch {
+		case 'b', 'B':
+			e.pos = e.wordStart()
+		case 'f', 'F':
+			e.pos = e.wordEnd()
+		case 'd', 'D':
+			e.killing = killing
+			e.kill(e.pos, e.wordEnd())
+		default:
+			return false
+		}
+	case key == gocui.KeySpace:
+		e.insert(' ')
+	case key == gocui.KeyEnter && mod == gocui.ModAlt:
+		e.insert('\n')
+	case key == gocui.KeyBackspace || key == gocui.KeyBackspace2:
+		e.backspace()
+	case key == gocui.KeyDelete:
+		e.deleteChar()
+	case key == gocui.KeyInsert:
+		e.overwrite = !e.overwrite
+	case key == gocui.KeyArrowLeft || key == gocui.KeyCtrlB:
+		e.left()
+	case key == gocui.KeyArrowRight || key == gocui.KeyCtrlF:
+		e.right()
+	case key == gocui.KeyHome || key == gocui.KeyCtrlA:
+		e.pos = e.lineStart()
+	case key == gocui.KeyEnd || key == gocui.KeyCtrlE:
+		e.pos = e.lineEnd()
+	case key == gocui.KeyCtrlW:
+		e.killing = killing
+		e.kill(e.argStart(), e.pos)
+	case key == gocui.KeyCtrlU:
+		e.killing = killing
+		e.kill(e.lineStart(), e.pos)
+	case key == gocui.KeyCtrlK:
+		e.killing = killing
+		e.killToEnd()
+	case key == gocui.KeyCtrlY:
+		e.yank()
+	default:
+		e.killing = killing
+		return false
+	}
+	return true
+}
+
+// render splits the text into rows of the width (by display width of characters) and returns them
+// with position of the cursor in the rows (column is in screen cells)
+func (e *lineEditor) render(width int) (rows []string, cx, cy int) {
+	if width < 2 {
+		width = 2
+	}
+	var row strings.Builder
+	w := 0
+	newRow := func() {
+		rows = append(rows, row.String())
+		row.Reset()
+		w = 0
+	}
+	for i, r := range e.text {
+		rw := runewidth.RuneWidth(r)
+		if r != '\n' && w+rw > width {
+			newRow()
+		}
+		if i == e.pos {
+			cx, cy = w, len(rows)
+		}
+		if r == '\n' {
+			newRow()
+			continue
+		}
+		row.WriteRune(r)
+		w += rw
+	}
+	if e.pos == len(e.text) {
+		// cursor after the last character (on the next row when the row is full)
+		if w >= width {
+			newRow()
+		}
+		cx, cy = w, len(rows)
+	}
+	newRow()
+	return rows, cx, cy
+}
+
+// editorKeysHelp returns description of the editor keys (one per line)
+func editorKeysHelp(keys [][2]string) string {
+	width := 0
+	for _, k := range keys {
+		if w := len(k[0]); w > width {
+			width = w
+		}
+	}
+	lines := []string{}
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf(" %-*s - %s", width, k[0], k[1]))
+	}
+	return strings.Join(lines, "\n")
+}
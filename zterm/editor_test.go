@@ -0,0 +1,182 @@
+package zterm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// keyPress is a key press for the editor
+type keyPress struct {
+	key gocui.Key
+	ch  rune
+	mod gocui.Modifier
+}
+
+func typeText(text string) []keyPress {
+	keys := []keyPress{}
+	for _, r := range text {
+		keys = append(keys, keyPress{ch: r})
+	}
+	return keys
+}
+
+// editText creates editor with text (cursor at pos) and presses the keys
+func editText(text string, pos int, keys ...keyPress) *lineEditor {
+	e := &lineEditor{}
+	e.setText(text)
+	e.pos = pos
+	for _, k := range keys {
+		e.handleKey(k.key, k.ch, k.mod)
+	}
+	return e
+}
+
+func TestLineEditorKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		pos  int
+		keys []keyPress
+		want string
+		pos2 int
+	}{
+		{"insert", "ac", 1, typeText("b"), "abc", 2},
+		{"insert unicode", "čž", 1, typeText("ř日"), "čř日ž", 3},
+		{"space", "ab", 1, []keyPress{{key: gocui.KeySpace}}, "a b", 2},
+		{"backspace", "čřž", 2, []keyPress{{key: gocui.KeyBackspace2}}, "čž", 1},
+		{"backspace at start", "ab", 0, []keyPress{{key: gocui.KeyBackspace}}, "ab", 0},
+		{"delete", "čřž", 1, []keyPress{{key: gocui.KeyDelete}}, "čž", 1},
+		{"delete at end", "ab", 2, []keyPress{{key: gocui.KeyDelete}}, "ab", 2},
+		{"left right", "abc", 1, []keyPress{{key: gocui.KeyArrowLeft}, {key: gocui.KeyArrowLeft}, {key: gocui.KeyCtrlF}}, "abc", 1},
+		{"home", "日本語", 2, []keyPress{{key: gocui.KeyHome}}, "日本語", 0},
+		{"end is in runes", "日本語", 0, []keyPress{{key: gocui.KeyEnd}}, "日本語", 3},
+		{"ctrl+a", "ab\ncd", 4, []keyPress{{key: gocui.KeyCtrlA}}, "ab\ncd", 3},
+		{"end of line", "ab\ncd", 0, []keyPress{{key: gocui.KeyCtrlE}}, "ab\ncd", 2},
+		{"alt+b", "view joblog hi-line", 19, []keyPress{{ch: 'b', mod: gocui.ModAlt}, {ch: 'b', mod: gocui.ModAlt}}, "view joblog hi-line", 12},
+		{"alt+f", "view joblog hi-line", 0, []keyPress{{ch: 'f', mod: gocui.ModAlt}, {ch: 'f', mod: gocui.ModAlt}}, "view joblog hi-line", 11},
+		{"alt+d", "view joblog hi", 4, []keyPress{{ch: 'd', mod: gocui.ModAlt}}, "view hi", 4},
+		{"ctrl+w", "remote ls -la /tmp", 18, []keyPress{{key: gocui.KeyCtrlW}}, "remote ls -la ", 14},
+		{"ctrl+w spaces", "remote ls   ", 12, []keyPress{{key: gocui.KeyCtrlW}}, "remote ", 7},
+		{"ctrl+k", "remote ls -la", 9, []keyPress{{key: gocui.KeyCtrlK}}, "remote ls", 9},
+		{"ctrl+k joins lines", "ab\ncd", 2, []keyPress{{key: gocui.KeyCtrlK}}, "abcd", 2},
+		{"ctrl+u", "ab\ncd", 5, []keyPress{{key: gocui.KeyCtrlU}}, "ab\n", 3},
+		{"ctrl+k ctrl+y", "remote ls -la", 9, []keyPress{{key: gocui.KeyCtrlK}, {key: gocui.KeyCtrlA}, {key: gocui.KeyCtrlY}}, " -laremote ls", 4},
+		{"ctrl+w twice yank", "a b c", 5, []keyPress{{key: gocui.KeyCtrlW}, {key: gocui.KeyCtrlW}, {key: gocui.KeyCtrlY}, {key: gocui.KeyCtrlY}}, "a b cb c", 8},
+		{"kill after move", "a b c", 5, []keyPress{{key: gocui.KeyCtrlW}, {key: gocui.KeyArrowLeft}, {key: gocui.KeyCtrlW}, {key: gocui.KeyCtrlE}, {key: gocui.KeyCtrlY}}, "a  b", 4},
+		{"alt+enter", "ab", 1, []keyPress{{key: gocui.KeyEnter, mod: gocui.ModAlt}}, "a\nb", 2},
+		{"overwrite", "abcd", 1, append([]keyPress{{key: gocui.KeyInsert}}, typeText("XYZW")...), "aXYZW", 5},
+		{"overwrite stops at line end", "ab\ncd", 1, append([]keyPress{{key: gocui.KeyInsert}}, typeText("XY")...), "aXY\ncd", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := editText(tt.text, tt.pos, tt.keys...)
+			if e.String() != tt.want || e.pos != tt.pos2 {
+				t.Errorf("got %q (cursor %d), want %q (cursor %d)", e.String(), e.pos, tt.want, tt.pos2)
+			}
+		})
+	}
+}
+
+func TestLineEditorUnhandledKeys(t *testing.T) {
+	e := &lineEditor{}
+	for _, k := range []keyPress{{key: gocui.KeyEnter}, {key: gocui.KeyArrowUp}, {key: gocui.KeyCtrlR}, {ch: 'x', mod: gocui.ModAlt}} {
+		if e.handleKey(k.key, k.ch, k.mod) {
+			t.Errorf("key %v (%q, mod %v) should not be handled", k.key, k.ch, k.mod)
+		}
+	}
+}
+
+func TestLineEditorMoveLine(t *testing.T) {
+	e := editText("abcdef\n日本\nxyz", 4)
+	if !e.moveLine(1) || e.pos != 9 {
+		// column 4 is after the second wide character
+		t.Errorf("down: cursor %d, want 9", e.pos)
+	}
+	if !e.moveLine(1) || e.pos != 13 {
+		// shorter line, cursor is at its end
+		t.Errorf("down: cursor %d, want 13", e.pos)
+	}
+	if e.moveLine(1) {
+		t.Errorf("down from the last line should fail")
+	}
+	e.pos = 11 // after 'x'
+	if !e.moveLine(-1) || e.pos != 7 {
+		// column 1 is in the middle of wide character, cursor is before it
+		t.Errorf("up: cursor %d, want 7", e.pos)
+	}
+	if !e.moveLine(-1) || e.pos != 0 {
+		t.Errorf("up: cursor %d, want 0", e.pos)
+	}
+	if e.moveLine(-1) {
+		t.Errorf("up from the first line should fail")
+	}
+}
+
+func TestLineEditorRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		pos    int
+		width  int
+		rows   []string
+		cx, cy int
+	}{
+		{"empty", "", 0, 10, []string{""}, 0, 0},
+		{"short", "ls -la", 6, 10, []string{"ls -la"}, 6, 0},
+		{"wide characters", "日本語", 3, 10, []string{"日本語"}, 6, 0},
+		{"wide character wrapped", "ab日本語", 3, 5, []string{"ab日", "本語"}, 0, 1},
+		{"cursor in wide text", "ab日本語", 4, 5, []string{"ab日", "本語"}, 2, 1},
+		{"full row", "abcde", 5, 5, []string{"abcde", ""}, 0, 1},
+		{"new lines", "ab\ncd\n", 4, 10, []string{"ab", "cd", ""}, 1, 1},
+		{"cursor at the end", "ab\ncd\n", 6, 10, []string{"ab", "cd", ""}, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := editText(tt.text, tt.pos)
+			rows, cx, cy := e.render(tt.width)
+			if !reflect.DeepEqual(rows, tt.rows) || cx != tt.cx || cy != tt.cy {
+				t.Errorf("got %q (cursor %d,%d), want %q (cursor %d,%d)", rows, cx, cy, tt.rows, tt.cx, tt.cy)
+			}
+		})
+	}
+}
+
+func TestLineEditorContinued(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{`remote ls`, false},
+		{`remote ls \`, true},
+		{`remote ls \\`, false},
+		{`remote ls \\\`, true},
+	}
+	for _, tt := range tests {
+		if got := editText(tt.text, len([]rune(tt.text))).continued(); got != tt.want {
+			t.Errorf("continued(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+	if editText(`ls \`, 2).continued() {
+		t.Errorf("line with cursor in the middle should not continue")
+	}
+}
+
+func TestJoinLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"addview x", "addview x"},
+		{"addview x\nattach x ls", "addview x; attach x ls"},
+		{"addview x &&\nattach x ls\n\n", "addview x && attach x ls"},
+		{"addview x;\n  attach x ls", "addview x; attach x ls"},
+		{"remote ls \\\n-la", "remote ls -la"},
+	}
+	for _, tt := range tests {
+		if got := joinLines(tt.text); got != tt.want {
+			t.Errorf("joinLines(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// History configuration of console commands
+type History struct {
+	Size  int    `mapstructure:"size,omitempty" yaml:"size,omitempty"`   // max number of saved commands (default 1000)
+	Scope string `mapstructure:"scope,omitempty" yaml:"scope,omitempty"` // global (default), workspace or server history file
+}
+
+// historySearch is a state of incremental reverse search in history (Ctrl+R)
+type historySearch struct {
+	query string
+	idx   int    // index of matched command in history (-1 when not found)
+	orig  string // command line before search started
+}
+
+const (
+	searchView         = "history-search"
+	defaultHistorySize = 1000
+)
+
+var histSearch *historySearch
+
+// historyFile returns path of the history file (`~/.zterm/history`, with workspace or server suffix by history scope)
+func historyFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	name := "history"
+	switch config.History.Scope {
+	case "workspace":
+		if currWorkspace != nil {
+			name += "-" + currWorkspace.name
+		}
+	case "server":
+		if config.Server.Host != "" {
+			name += "-" + config.Server.User + "@" + config.Server.Host
+		}
+	}
+	return filepath.Join(home, ".zterm", name)
+}
+
+// historySize returns max number of commands in history
+func historySize() int {
+	if config.History.Size > 0 {
+		return config.History.Size
+	}
+	return defaultHistorySize
+}
+
+// loadHistory loads command history from the history file
+func (wc *WidgetConsole) loadHistory() {
+	wc.cmdHistory = []string{}
+	if data, err := os.ReadFile(historyFile()); err == nil {
+		for _, cmd := range strings.Split(string(data), "\n") {
+			if cmd != "" {
+				wc.cmdHistory = append(wc.cmdHistory, cmd)
+			}
+		}
+	}
+	wc.histIndex = len(wc.cmdHistory)
+}
+
+// addHistory adds command to the history (older same command is removed) and saves history into the file.
+// Error of saving is returned only the first time (history is still kept for the session).
+func (wc *WidgetConsole) addHistory(cmd string) error {
+	if strings.TrimSpace(cmd) != "" {
+		hist := []string{}
+		for _, c := range wc.cmdHistory {
+			if c != cmd {
+				hist = append(hist, c)
+			}
+		}
+		hist = append(hist, cmd)
+		if len(hist) > historySize() {
+			hist = hist[len(hist)-historySize():]
+		}
+		wc.cmdHistory = hist
+		if err := wc.saveHistory(); err != nil && !wc.histFailed {
+			wc.histFailed = true
+			wc.histIndex = len(wc.cmdHistory)
+			return fmt.Errorf("history not saved: %v", err)
+		}
+	}
+	wc.histIndex = len(wc.cmdHistory)
+	return nil
+}
+
+// saveHistory writes command history into the history file
+func (wc *WidgetConsole) saveHistory() error {
+	file := historyFile()
+	if file == "" {
+		return errors.New("history: home directory not found")
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(strings.Join(wc.cmdHistory, "\n")+"\n"), 0600)
+}
+
+// startSearch starts incremental reverse search in history
+func (wc *WidgetConsole) startSearch() {
+	histSearch = &historySearch{idx: len(wc.cmdHistory), orig: wc.editor.String()}
+	wc.searchHistory(histSearch.idx - 1)
+}
+
+// searchHistory finds command containing query from the index back in history and put it into the prompt
+func (wc *WidgetConsole) searchHistory(from int) {
+	if from >= len(wc.cmdHistory) {
+		from = len(wc.cmdHistory) - 1
+	}
+	for i := from; i >= 0; i-- {
+		if strings.Contains(wc.cmdHistory[i], histSearch.query) {
+			histSearch.idx = i
+			wc.editor.setText(wc.cmdHistory[i])
+			return
+		}
+	}
+	if histSearch.query == "" {
+		wc.editor.setText(histSearch.orig)
+	} else {
+		// failing search, previous match is not run by Enter
+		wc.editor.setText("")
+	}
+	histSearch.idx = -1
+}
+
+// searchEditor handles keys during reverse search (returns false when the key should be handled by the editor)
+func (wc *WidgetConsole) searchEditor(key gocui.Key, ch rune, mod gocui.Modifier) bool {
+	switch {
+	case key == gocui.KeyCtrlR:
+		// next older match
+		if histSearch.idx > 0 {
+			wc.searchHistory(histSearch.idx - 1)
+		}
+	case key == gocui.KeyCtrlG:
+		// cancel search
+		wc.editor.setText(histSearch.orig)
+		histSearch = nil
+	case key == gocui.KeyBackspace || key == gocui.KeyBackspace2:
+		if q := []rune(histSearch.query); len(q) > 0 {
+			histSearch.query = string(q[:len(q)-1])
+		}
+		wc.searchHistory(len(wc.cmdHistory) - 1)
+	case ch != 0 && mod == 0, key == gocui.KeySpace:
+		if key == gocui.KeySpace {
+			ch = ' '
+		}
+		histSearch.query += string(ch)
+		from := histSearch.idx
+		if from < 0 {
+			from = len(wc.cmdHistory) - 1
+		}
+		wc.searchHistory(from)
+	default:
+		// any other key accepts found command (and is processed by editor)
+		histSearch = nil
+		return false
+	}
+	return true
+}
+
+// layoutSearch displays query of reverse search on the line above console prompt
+func layoutSearch(g *gocui.Gui, x0, y, x1 int) error {
+	if histSearch == nil {
+		g.DeleteView(searchView) // if doesn't exist, don't care
+		return nil
+	}
+	v, err := g.SetView(searchView, x0, y-1, x1, y+1, 0)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return fmt.Errorf("view %v: %v", searchView, err)
+	}
+	v.Frame = false
+	v.Clear()
+	label := "reverse-i-search"
+	if histSearch.idx < 0 && histSearch.query != "" {
+		label = "failing " + label
+	}
+	fmt.Fprint(v, colorText(fmt.Sprintf("(%s)`%s'", label, histSearch.query), cConsoleStr))
+	g.SetViewOnTop(searchView)
+	return nil
+}
@@ -0,0 +1,181 @@
+package zterm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// jobOptions are options of the view job (nil options run the command in zterm directory and environment)
+type jobOptions struct {
+	cwd     string        // working directory
+	env     []string      // environment variables added to the command (`name=value`)
+	shell   string        // shell of local command (sh by default, bash, zsh or none to execute it directly)
+	timeout time.Duration // job is killed after the timeout
+}
+
+// jobShells are shells which can run local commands (none executes the command directly)
+var jobShells = []string{"sh", "bash", "zsh", "none"}
+
+// newJobOptions returns job options from view configuration (nil when nothing is set)
+func newJobOptions(v View) (*jobOptions, error) {
+	if v.Cwd == "" && len(v.Env) == 0 && v.Shell == "" && v.Timeout == "" {
+		return nil, nil
+	}
+	opts := &jobOptions{cwd: v.Cwd, env: v.Env, shell: v.Shell}
+	for _, kv := range opts.env {
+		if name, _, ok := strings.Cut(kv, "="); !ok || name == "" {
+			return nil, fmt.Errorf("env: invalid variable '%s' (use NAME=value)", kv)
+		}
+	}
+	if opts.shell != "" && !containsString(jobShells, opts.shell) {
+		return nil, fmt.Errorf("shell %s not supported (use %s)", opts.shell, strings.Join(jobShells, ", "))
+	}
+	if v.Timeout != "" {
+		d, err := time.ParseDuration(v.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("timeout: invalid duration '%s' (use duration like 30s or 1m)", v.Timeout)
+		}
+		opts.timeout = d
+	}
+	return opts, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// context returns context of the job (with timeout if it's set)
+func (o *jobOptions) context() (context.Context, context.CancelFunc) {
+	if o != nil && o.timeout > 0 {
+		return context.WithTimeout(context.Background(), o.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// timeoutError returns clear error when the job was killed because of the timeout (other errors are kept)
+func (o *jobOptions) timeoutError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: job killed after %v", errJobTimeout, o.timeout)
+	}
+	return err
+}
+
+// envList returns environment variables as list of `name=value` (only the last value of repeated name is kept)
+func (o *jobOptions) envList() []string {
+	list := []string{}
+	if o == nil {
+		return list
+	}
+	for i, kv := range o.env {
+		name, _, _ := strings.Cut(kv, "=")
+		if envIndex(o.env[i+1:], name) < 0 {
+			list = append(list, kv)
+		}
+	}
+	return list
+}
+
+// envIndex returns index of the variable in the list of `name=value` (-1 if it's not there)
+func envIndex(env []string, name string) int {
+	for i, kv := range env {
+		if strings.HasPrefix(kv, name+"=") {
+			return i
+		}
+	}
+	return -1
+}
+
+// command returns local command with shell, working directory and environment of the job
+func (o *jobOptions) command(ctx context.Context, command string) (*exec.Cmd, error) {
+	if o == nil {
+		c := exec.CommandContext(ctx, "sh", "-c", command)
+		setProcessGroup(c)
+		return c, nil
+	}
+	var c *exec.Cmd
+	switch o.shell {
+	case "", "sh", "bash", "zsh":
+		shell := o.shell
+		if shell == "" {
+			shell = "sh"
+		}
+		c = exec.CommandContext(ctx, shell, "-c", command)
+	case "none":
+		// command is executed directly (arguments are split like in the console)
+		cl, err := tokenize(command)
+		if err != nil {
+			return nil, fmt.Errorf("parse: %v", err)
+		}
+		if len(cl.args) == 0 {
+			return nil, errors.New("shell none: requires command")
+		}
+		c = exec.CommandContext(ctx, cl.args[0], cl.args[1:]...)
+	}
+	setProcessGroup(c)
+	if o.cwd != "" {
+		c.Dir = expandHome(o.cwd)
+	}
+	if len(o.env) > 0 {
+		c.Env = append(os.Environ(), o.envList()...)
+	}
+	return c, nil
+}
+
+// startCommand starts local command of the job, its process group is killed on timeout or cancel
+func startCommand(ctx context.Context, c *exec.Cmd) error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	killGroupOnDone(ctx, c)
+	return nil
+}
+
+// remote setups environment of the ssh session and returns command changing directory before the job.
+// Environment variables not accepted by the server are exported by the command.
+func (o *jobOptions) remote(session *ssh.Session, command string) string {
+	if o == nil {
+		return command
+	}
+	exports := []string{}
+	for _, kv := range o.envList() {
+		k, v, _ := strings.Cut(kv, "=")
+		if err := session.Setenv(k, v); err != nil {
+			exports = append(exports, k+"="+quoteArg(v))
+		}
+	}
+	if len(exports) > 0 {
+		command = "export " + strings.Join(exports, " ") + "; " + command
+	}
+	if o.cwd != "" {
+		dir := quoteArg(o.cwd)
+		if strings.HasPrefix(o.cwd, "~/") {
+			// home directory is expanded by the remote shell
+			dir = "~/" + quoteArg(o.cwd[2:])
+		}
+		command = "cd " + dir + " && " + command
+	}
+	return command
+}
+
+// expandHome replaces `~/` at the start of the path by home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,120 @@
+package zterm
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// loadConfig reads YAML configuration the same way as zterm does at startup
+func loadConfig(t *testing.T, file string) Config {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestJobEnvConfigRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "zterm.yml")
+	yml := `
+views:
+  build:
+    job: make test
+    env:
+      - JAVA_HOME=/opt/java
+      - GOFLAGS=-count=1
+`
+	if err := os.WriteFile(file, []byte(yml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"JAVA_HOME=/opt/java", "GOFLAGS=-count=1"}
+	cfg := loadConfig(t, file)
+	if got := cfg.Views["build"].Env; !reflect.DeepEqual(got, want) {
+		t.Fatalf("loaded env %q, want %q", got, want)
+	}
+
+	// save the view like zterm does and load it again
+	out := viper.New()
+	if err := out.MergeConfigMap(map[string]interface{}{"views": map[string]interface{}{"build": cfg.Views["build"]}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.WriteConfigAs(file); err != nil {
+		t.Fatal(err)
+	}
+	cfg = loadConfig(t, file)
+	if got := cfg.Views["build"].Env; !reflect.DeepEqual(got, want) {
+		t.Fatalf("saved env %q, want %q", got, want)
+	}
+
+	opts, err := newJobOptions(cfg.Views["build"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := opts.envList(); !reflect.DeepEqual(got, want) {
+		t.Errorf("job env %q, want %q", got, want)
+	}
+}
+
+func TestJobEnvInvalid(t *testing.T) {
+	for _, env := range []string{"JAVA_HOME", "=value"} {
+		if _, err := newJobOptions(View{Env: []string{env}}); err == nil {
+			t.Errorf("env %q: expected error", env)
+		}
+	}
+}
+
+func TestJobEnvListLastWins(t *testing.T) {
+	opts := &jobOptions{env: []string{"A=1", "B=2", "A=3"}}
+	if got, want := opts.envList(), []string{"B=2", "A=3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("env %q, want %q", got, want)
+	}
+}
+
+func TestJobTimeoutKillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no process groups on windows")
+	}
+	opts := &jobOptions{timeout: 200 * time.Millisecond}
+	ctx, cancel := opts.context()
+	defer cancel()
+	// sleep keeps the output open after sh is killed (unless the whole process group is killed)
+	c, err := opts.command(ctx, "sleep 100 | cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := startCommand(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		io.Copy(io.Discard, out)
+		done <- c.Wait()
+	}()
+	select {
+	case err := <-done:
+		if err := opts.timeoutError(ctx, err); !errors.Is(err, errJobTimeout) {
+			t.Errorf("error %v, want timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job not killed after timeout")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package zterm
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs the command in its own process group (so its children can be killed with it)
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killGroupOnDone kills the whole process group of started command when the context is done.
+// Only the command itself is killed by the context, its children (like `sleep 100 | cat`) would keep the output open.
+func killGroupOnDone(ctx context.Context, c *exec.Cmd) {
+	go func() {
+		<-ctx.Done()
+		syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}()
+}
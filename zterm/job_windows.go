@@ -0,0 +1,12 @@
+package zterm
+
+import (
+	"context"
+	"os/exec"
+)
+
+// setProcessGroup does nothing on windows (there are no process groups)
+func setProcessGroup(c *exec.Cmd) {}
+
+// killGroupOnDone does nothing on windows (only the command is killed by the context)
+func killGroupOnDone(ctx context.Context, c *exec.Cmd) {}
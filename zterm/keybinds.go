@@ -1,44 +1,191 @@
-package zterm
-
-import (
-	"log"
-
-	"github.com/awesome-gocui/gocui"
-)
-
-// Global Keybinds setup
-func keybindsGlobal(g *gocui.Gui) {
-	// quit
-	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
-		log.Panicln(err)
-	}
-	if err := g.SetKeybinding("", gocui.KeyF10, gocui.ModNone, quit); err != nil {
-		log.Panicln(err)
-	}
-
-	// tab thru next
-	if err := g.SetKeybinding("", gocui.KeyTab, gocui.ModNone, changeView); err != nil {
-		log.Panicln(err)
-	}
-
-	// help
-	if err := g.SetKeybinding("", gocui.KeyF1, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		for _, w := range widgets {
-			if w.GetName() == "help-window" {
-				return closeFloatyWidget(g, v)
-			}
-		}
-		PopupHelpWidget()
-		return nil
-	}); err != nil {
-		log.Panicln(err)
-	}
-
-	// console - Esc or ` to turn on (Esc is to turn off too)
-	if err := g.SetKeybinding("", gocui.KeyEsc, gocui.ModNone, showConsole); err != nil {
-		log.Panicln(err)
-	}
-	if err := g.SetKeybinding("", '`', gocui.ModNone, showConsole); err != nil {
-		log.Panicln(err)
-	}
-}
+package zterm
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// keybind is a key binding with description (displayed in help)
+type keybind struct {
+	view string
+	key  interface{}
+	mod  gocui.Modifier
+	help string
+}
+
+// keybindList contains all active key bindings (in order as they were set)
+var keybindList []keybind
+
+// names of the special keys
+var keyNames = map[gocui.Key]string{
+	gocui.KeyF1:          "F1",
+	gocui.KeyF10:         "F10",
+	gocui.KeyCtrlC:       "Ctrl+C",
+	gocui.KeyCtrlR:       "Ctrl+R",
+	gocui.KeyCtrlV:       "Ctrl+V",
+	gocui.KeyCtrlZ:       "Ctrl+Z",
+	gocui.KeyTab:         "Tab",
+	gocui.KeyBacktab:     "Shift+Tab",
+	gocui.KeyEsc:         "Esc",
+	gocui.KeyEnter:       "Enter",
+	gocui.KeyArrowUp:     "Up",
+	gocui.KeyArrowDown:   "Down",
+	gocui.KeyArrowLeft:   "Left",
+	gocui.KeyArrowRight:  "Right",
+	gocui.KeyPgup:        "PgUp",
+	gocui.KeyPgdn:        "PgDn",
+	gocui.KeyHome:        "Home",
+	gocui.KeyEnd:         "End",
+	gocui.MouseLeft:      "Mouse click",
+	gocui.MouseRelease:   "Mouse release",
+	gocui.MouseWheelUp:   "Mouse wheel up",
+	gocui.MouseWheelDown: "Mouse wheel down",
+	gocui.Key(0):         "Mouse move",
+}
+
+// setKeybinding sets key binding for the view and keeps its description for help (F1)
+func setKeybinding(g *gocui.Gui, view string, key interface{}, mod gocui.Modifier, help string, handler func(*gocui.Gui, *gocui.View) error) error {
+	if err := g.SetKeybinding(view, key, mod, handler); err != nil {
+		return err
+	}
+	for i, kb := range keybindList {
+		if kb.view == view && kb.key == key && kb.mod == mod {
+			keybindList[i].help = help
+			return nil
+		}
+	}
+	keybindList = append(keybindList, keybind{view, key, mod, help})
+	return nil
+}
+
+// deleteKeybinding deletes key binding of the view
+func deleteKeybinding(g *gocui.Gui, view string, key interface{}, mod gocui.Modifier) error {
+	for i, kb := range keybindList {
+		if kb.view == view && kb.key == key && kb.mod == mod {
+			keybindList = append(keybindList[:i], keybindList[i+1:]...)
+			break
+		}
+	}
+	return g.DeleteKeybinding(view, key, mod)
+}
+
+// deleteKeybindings deletes all key bindings of the view
+func deleteKeybindings(g *gocui.Gui, view string) {
+	kbs := []keybind{}
+	for _, kb := range keybindList {
+		if kb.view != view {
+			kbs = append(kbs, kb)
+		}
+	}
+	keybindList = kbs
+	g.DeleteKeybindings(view)
+}
+
+// keyName returns readable name of the key (like `Ctrl+R` or `Alt+1`)
+func keyName(key interface{}, mod gocui.Modifier) string {
+	name := fmt.Sprint(key)
+	switch k := key.(type) {
+	case gocui.Key:
+		if n, ok := keyNames[k]; ok {
+			name = n
+		}
+	case rune:
+		name = string(k)
+		if k == ' ' {
+			name = "Space"
+		}
+	}
+	if mod == gocui.ModAlt {
+		name = "Alt+" + name
+	}
+	return name
+}
+
+// keysHelp returns description of key bindings of the view (keys with the same description are on one line)
+func keysHelp(view string) string {
+	keys := map[string][]string{}
+	order := []string{}
+	for _, kb := range keybindList {
+		if kb.view != view {
+			continue
+		}
+		if _, ok := keys[kb.help]; !ok {
+			order = append(order, kb.help)
+		}
+		keys[kb.help] = append(keys[kb.help], keyName(kb.key, kb.mod))
+	}
+	width := 0
+	for _, help := range order {
+		if w := len(joinKeys(keys[help])); w > width {
+			width = w
+		}
+	}
+	lines := []string{}
+	for _, help := range order {
+		lines = append(lines, fmt.Sprintf(" %-*s - %s", width, joinKeys(keys[help]), help))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// joinKeys joins key names, sequence of keys which differ only by last digit is shortened (like `Alt+1..9`)
+func joinKeys(names []string) string {
+	if len(names) > 2 {
+		first, last := names[0], names[len(names)-1]
+		prefix, digit := first[:len(first)-1], first[len(first)-1]
+		seq := digit >= '0' && digit <= '9'
+		for i, n := range names {
+			seq = seq && n == prefix+string(rune(digit)+rune(i))
+		}
+		if seq {
+			return first + ".." + last[len(last)-1:]
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Global Keybinds setup
+func keybindsGlobal(g *gocui.Gui) {
+	// quit
+	if err := setKeybinding(g, "", gocui.KeyCtrlC, gocui.ModNone, "exit zterm", quit); err != nil {
+		log.Panicln(err)
+	}
+	if err := setKeybinding(g, "", gocui.KeyF10, gocui.ModNone, "exit zterm", quit); err != nil {
+		log.Panicln(err)
+	}
+
+	// tab thru next
+	if err := setKeybinding(g, "", gocui.KeyTab, gocui.ModNone, "select next view", changeView); err != nil {
+		log.Panicln(err)
+	}
+
+	// help
+	if err := setKeybinding(g, "", gocui.KeyF1, gocui.ModNone, "display or close this help", func(g *gocui.Gui, v *gocui.View) error {
+		if hv, err := g.View(helpView); err == nil {
+			return closeFloatyWidget(g, hv)
+		}
+		PopupHelpWidget(v)
+		return nil
+	}); err != nil {
+		log.Panicln(err)
+	}
+
+	// mouse - click, drag and wheel
+	keybindsMouse(g)
+
+	// visual selection and copy to clipboard
+	keybindsSelection(g)
+
+	// workspaces - Alt+1..9
+	keybindsWorkspace(g)
+
+	// console - Esc or ` to turn on (Esc is to turn off too)
+	if err := setKeybinding(g, "", gocui.KeyEsc, gocui.ModNone, "open or close console", showConsole); err != nil {
+		log.Panicln(err)
+	}
+	if err := setKeybinding(g, "", '`', gocui.ModNone, "open or close console", showConsole); err != nil {
+		log.Panicln(err)
+	}
+}
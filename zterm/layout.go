@@ -0,0 +1,498 @@
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// LayoutNode is a node of the layout tree. Node is either a view (leaf) or a split of nodes into rows or columns.
+//
+// Weight of a node is specified by `weight`, otherwise it's the size of the view or computed from the split items.
+type LayoutNode struct {
+	Split  string        `mapstructure:"split,omitempty" yaml:"split,omitempty"` // rows (top to bottom) or columns (left to right)
+	View   string        `mapstructure:"view,omitempty" yaml:"view,omitempty"`
+	Weight int           `mapstructure:"weight,omitempty" yaml:"weight,omitempty"`
+	Items  []*LayoutNode `mapstructure:"items,omitempty" yaml:"items,omitempty"`
+	rect   layoutRect    // position on the screen (from last arrange)
+}
+
+// layoutRect is a position of the view on the screen
+type layoutRect struct {
+	x0, y0, x1, y1 int
+	overlaps       byte
+}
+
+var (
+	layoutRoot  *LayoutNode
+	layoutRects = map[string]layoutRect{}
+	zoomedView  string // view displayed over the whole screen
+)
+
+// setupLayout prepares layout tree of each workspace from configuration. Views which are not in the tree are added at the bottom.
+func setupLayout() {
+	for _, wsp := range workspaces {
+		layoutRoot = wsp.layout
+		if layoutRoot == nil {
+			layoutRoot = &LayoutNode{Split: "rows"}
+		}
+		// remove views which doesn't exist in the workspace
+		var clean func(n *LayoutNode)
+		clean = func(n *LayoutNode) {
+			items := []*LayoutNode{}
+			for _, it := range n.Items {
+				clean(it)
+				if ws := getWidgetStack(it.View); it.View != "" && (ws == nil || ws.workspace != wsp.name) {
+					continue
+				}
+				if it.View == "" && len(it.Items) == 0 {
+					continue
+				}
+				items = append(items, it)
+			}
+			n.Items = items
+		}
+		clean(layoutRoot)
+		stacks := wsp.stacks()
+		sort.Slice(stacks, func(i, j int) bool {
+			return stacks[i].pos < stacks[j].pos
+		})
+		for _, ws := range stacks {
+			if layoutRoot.find(ws.name) == nil {
+				layoutAddView(ws.name)
+			}
+		}
+		wsp.layout = layoutRoot
+	}
+	layoutRoot = currWorkspace.layout
+	layoutRenumber()
+}
+
+// layoutAddView adds view at the bottom of the layout
+func layoutAddView(name string) {
+	if layoutRoot.Split != "rows" {
+		// wrap root into rows
+		layoutRoot = &LayoutNode{Split: "rows", Items: []*LayoutNode{layoutRoot}}
+	}
+	layoutRoot.Items = append(layoutRoot.Items, &LayoutNode{View: name})
+}
+
+// find returns leaf node of the view
+func (n *LayoutNode) find(name string) *LayoutNode {
+	if n.View == name {
+		return n
+	}
+	for _, it := range n.Items {
+		if found := it.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// path returns list of nodes from this node to the leaf node of the view
+func (n *LayoutNode) path(name string) []*LayoutNode {
+	if n.View == name {
+		return []*LayoutNode{n}
+	}
+	for _, it := range n.Items {
+		if p := it.path(name); p != nil {
+			return append([]*LayoutNode{n}, p...)
+		}
+	}
+	return nil
+}
+
+// walk thru all the view nodes in order (top to bottom, left to right)
+func (n *LayoutNode) walk(fn func(n *LayoutNode)) {
+	if n.View != "" {
+		fn(n)
+	}
+	for _, it := range n.Items {
+		it.walk(fn)
+	}
+}
+
+// visible checks if there is at least one view displayed in the node
+func (n *LayoutNode) visible() bool {
+	if n.View != "" {
+		ws := getWidgetStack(n.View)
+		return ws != nil && !ws.IsHidden()
+	}
+	for _, it := range n.Items {
+		if it.visible() {
+			return true
+		}
+	}
+	return false
+}
+
+// weight of the node in the split direction (size of view or specified weight)
+func (n *LayoutNode) weight(split string) int {
+	if n.Weight > 0 {
+		return n.Weight
+	}
+	if n.View != "" {
+		if ws := getWidgetStack(n.View); ws != nil {
+			return ws.height
+		}
+		return 0
+	}
+	// items in the same direction are summed up, otherwise the biggest is used
+	w := 0
+	for _, it := range n.Items {
+		if !it.visible() {
+			continue
+		}
+		if iw := it.weight(split); n.Split == split {
+			w += iw
+		} else if iw > w {
+			w = iw
+		}
+	}
+	return w
+}
+
+// remove view node from the tree (splits with one item are collapsed)
+func (n *LayoutNode) remove(name string) bool {
+	for i, it := range n.Items {
+		if it.View == name {
+			n.Items = append(n.Items[:i], n.Items[i+1:]...)
+			return true
+		}
+		if it.remove(name) {
+			if len(it.Items) == 1 {
+				n.Items[i] = it.Items[0]
+			} else if len(it.Items) == 0 {
+				n.Items = append(n.Items[:i], n.Items[i+1:]...)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// visibleItems returns items of the split which are displayed
+func (n *LayoutNode) visibleItems() (items []*LayoutNode) {
+	for _, it := range n.Items {
+		if it.visible() {
+			items = append(items, it)
+		}
+	}
+	return
+}
+
+// setWeight sets weight of the node (size of the view if weight is not specified for it)
+func (n *LayoutNode) setWeight(w int) {
+	if w < 1 {
+		w = 1
+	}
+	if n.View != "" && n.Weight == 0 {
+		if ws := getWidgetStack(n.View); ws != nil {
+			viewMaxSize += w - ws.height
+			ws.height = w
+			if vmap, ok := config.Views[n.View]; ok {
+				vmap.Size = w
+				config.Views[n.View] = vmap
+			}
+		}
+		return
+	}
+	n.Weight = w
+}
+
+// arrange computes position of views in the node
+func (n *LayoutNode) arrange(r layoutRect, rects map[string]layoutRect) {
+	n.rect = r
+	if n.View != "" {
+		rects[n.View] = r
+		return
+	}
+	items := n.visibleItems()
+	total := 0
+	for _, it := range items {
+		total += it.weight(n.Split)
+	}
+	if len(items) == 0 {
+		return
+	}
+	from, to := r.y0, r.y1
+	if n.Split == "columns" {
+		from, to = r.x0, r.x1
+	}
+	cum := 0
+	start := from
+	for i, it := range items {
+		cum += it.weight(n.Split)
+		end := to
+		if i < len(items)-1 {
+			if total > 0 {
+				end = from + (to-from)*cum/total
+			} else {
+				end = from + (to-from)*(i+1)/len(items)
+			}
+		}
+		// at least one line for content, but keep the space for next items (when there is enough)
+		if rest := to - 2*(len(items)-1-i); end > rest && rest >= start+2 {
+			end = rest
+		}
+		if end < start+2 {
+			end = start + 2
+		}
+		if end > to {
+			if start+2 > to {
+				// no space left, views which don't fit are not displayed
+				break
+			}
+			end = to
+		}
+		if end+2 > to {
+			// next items don't fit, use the rest of the space
+			end = to
+		}
+		ir := r
+		if n.Split == "columns" {
+			ir.x0, ir.x1 = start, end
+			if i > 0 {
+				ir.overlaps |= gocui.LEFT
+			}
+		} else {
+			ir.y0, ir.y1 = start, end
+			if i > 0 {
+				ir.overlaps |= gocui.TOP
+			}
+		}
+		it.arrange(ir, rects)
+		start = end // share the border with next item
+	}
+}
+
+// arrangeLayout computes position of all the views on the screen
+func arrangeLayout(g *gocui.Gui) {
+	if layoutRoot == nil {
+		return
+	}
+	maxX, maxY := g.Size()
+	y0 := 0
+	if len(workspaces) > 1 {
+		// space for tab bar
+		y0 = 1
+	}
+	rects := map[string]layoutRect{}
+	if ws := getWidgetStack(zoomedView); ws != nil && ws.workspace == currWorkspace.name && !ws.IsHidden() {
+		// only zoomed view is displayed (others are hidden, but still updated)
+		rects[zoomedView] = layoutRect{x0: 0, y0: y0, x1: maxX - 1, y1: maxY - 1}
+	} else {
+		zoomedView = ""
+		layoutRoot.arrange(layoutRect{x0: 0, y0: y0, x1: maxX - 1, y1: maxY - 1}, rects)
+	}
+	layoutRects = rects
+}
+
+// toggleZoom displays selected view over the whole screen, or restores the layout if it's zoomed already
+func toggleZoom(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	if zoomedView != "" {
+		zoomedView = ""
+	} else {
+		zoomedView = v.Name()
+	}
+	arrangeLayout(g)
+	return nil
+}
+
+// layoutRenumber sets position of views by the order in layout tree (used for Tab order)
+func layoutRenumber() {
+	pos := 0
+	layoutRoot.walk(func(n *LayoutNode) {
+		ws := getWidgetStack(n.View)
+		if ws == nil {
+			return
+		}
+		pos++
+		ws.pos = pos
+		if vmap, ok := config.Views[n.View]; ok {
+			vmap.Position = pos
+			config.Views[n.View] = vmap
+		}
+	})
+	viewFirstPos, viewLastPos, viewMaxSize = -1, pos, 0
+	if pos > 0 {
+		viewFirstPos = 1
+	}
+	for _, ws := range getSortedWidgetStack() {
+		viewMaxSize += ws.height
+	}
+	sortWidgetManager(widgets)
+}
+
+// isDefault checks if layout is just views in rows (so it doesn't need to be saved)
+func (n *LayoutNode) isDefault() bool {
+	if n.Split != "rows" || n.Weight > 0 {
+		return false
+	}
+	for _, it := range n.Items {
+		if it.View == "" || it.Weight > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// layoutSplit puts the other view next to the view (columns) or below it (rows)
+func layoutSplit(view string, other string, split string) error {
+	if split != "rows" && split != "columns" {
+		return fmt.Errorf("split: %v is not rows or columns", split)
+	}
+	if view == other {
+		return errors.New("split: cannot split view with itself")
+	}
+	layoutRoot.remove(other)
+	p := layoutRoot.path(view)
+	if p == nil {
+		return fmt.Errorf("split: view '%s' is not in layout", view)
+	}
+	leaf := p[len(p)-1]
+	if len(p) > 1 && p[len(p)-2].Split == split {
+		// parent is already split the same way, add next to the view
+		parent := p[len(p)-2]
+		for i, it := range parent.Items {
+			if it == leaf {
+				parent.Items = append(parent.Items[:i+1], append([]*LayoutNode{{View: other}}, parent.Items[i+1:]...)...)
+				break
+			}
+		}
+	} else {
+		// replace view with split of both
+		*leaf = LayoutNode{Split: split, Items: []*LayoutNode{{View: view}, {View: other}}}
+	}
+	layoutRenumber()
+	return nil
+}
+
+// layoutMove moves the view in direction (up, down, left, right) in the layout
+func layoutMove(view string, dir string) error {
+	axis, step := "rows", -1
+	switch dir {
+	case "up":
+	case "down":
+		step = 1
+	case "left":
+		axis = "columns"
+	case "right":
+		axis, step = "columns", 1
+	default:
+		return fmt.Errorf("move: direction %v is not up, down, left or right", dir)
+	}
+	p := layoutRoot.path(view)
+	if p == nil {
+		return fmt.Errorf("move: view '%s' is not in layout", view)
+	}
+	leaf := p[len(p)-1]
+	// find closest split in the direction
+	j := len(p) - 2
+	for ; j >= 0 && p[j].Split != axis; j-- {
+	}
+	if j < 0 {
+		return fmt.Errorf("move: view '%s' cannot move %v", view, dir)
+	}
+	split, child := p[j], p[j+1]
+	idx := 0
+	for i, it := range split.Items {
+		if it == child {
+			idx = i
+		}
+	}
+	if child == leaf {
+		// swap with neighbor
+		if idx+step < 0 || idx+step >= len(split.Items) {
+			return fmt.Errorf("move: view '%s' is already at the edge", view)
+		}
+		split.Items[idx], split.Items[idx+step] = split.Items[idx+step], split.Items[idx]
+	} else {
+		// move view out of the nested split
+		layoutRoot.remove(view)
+		// split could be collapsed, find the child again
+		for i, it := range split.Items {
+			if it == child || it.find(child.firstView()) != nil {
+				idx = i
+			}
+		}
+		if step > 0 {
+			idx++
+		}
+		split.Items = append(split.Items[:idx], append([]*LayoutNode{{View: view}}, split.Items[idx:]...)...)
+	}
+	layoutRenumber()
+	return nil
+}
+
+// layoutMoveTo moves the view to the position (in order of views), next to the view which is there now
+func layoutMoveTo(view string, pos int) error {
+	var target *LayoutNode
+	curr := 0
+	layoutRoot.walk(func(n *LayoutNode) {
+		if ws := getWidgetStack(n.View); ws != nil && ws.pos == pos {
+			target = n
+		}
+		if n.View == view {
+			curr = getWidgetStack(n.View).pos
+		}
+	})
+	if curr == 0 {
+		return fmt.Errorf("move: view '%s' is not in layout", view)
+	}
+	if target == nil {
+		return fmt.Errorf("move: position %v is not in range %v-%v", pos, viewFirstPos, viewLastPos)
+	}
+	if target.View == view {
+		return nil
+	}
+	other := target.View
+	layoutRoot.remove(view)
+	p := layoutRoot.path(other)
+	if len(p) < 2 {
+		// root is the only view, wrap it into rows
+		layoutRoot = &LayoutNode{Split: "rows", Items: []*LayoutNode{layoutRoot}}
+		p = layoutRoot.path(other)
+	}
+	parent, leaf := p[len(p)-2], p[len(p)-1]
+	for i, it := range parent.Items {
+		if it == leaf {
+			// moving up goes before the view, moving down after it
+			if pos > curr {
+				i++
+			}
+			parent.Items = append(parent.Items[:i], append([]*LayoutNode{{View: view}}, parent.Items[i:]...)...)
+			break
+		}
+	}
+	layoutRenumber()
+	return nil
+}
+
+// firstView returns name of the first view in the node
+func (n *LayoutNode) firstView() string {
+	name := ""
+	n.walk(func(vn *LayoutNode) {
+		if name == "" {
+			name = vn.View
+		}
+	})
+	return name
+}
+
+// layoutSwap swaps position of two views in the layout
+func layoutSwap(view string, other string) error {
+	n1, n2 := layoutRoot.find(view), layoutRoot.find(other)
+	if n1 == nil || n2 == nil {
+		return errors.New("swap: both views have to be in layout")
+	}
+	n1.View, n2.View = n2.View, n1.View
+	layoutRenumber()
+	return nil
+}
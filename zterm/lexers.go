@@ -0,0 +1,136 @@
+package zterm
+
+import (
+	"regexp"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+)
+
+var (
+	jclJobRe     = regexp.MustCompile(`(?m)^//\S*\s+JOB\b`)
+	jclStmtRe    = regexp.MustCompile(`(?m)^//\S*\s+(EXEC|DD)\b`)
+	rexxHeaderRe = regexp.MustCompile(`(?i)\A\s*/\*.*\bREXX\b`)
+	syslogLineRe = regexp.MustCompile(`(?m)^[ A-Z][ A-Z]\S*\s+\S+\s+\S{1,8}\s+\d{5} \d{2}:\d{2}:\d{2}\.\d{2}`)
+	syslogMsgRe  = regexp.MustCompile(`\b(IEF|IEA|IEE|IAT|HASP|IXC|IST|DFH|CSV|ICH|IRR)\w*\d{3,5}[IEWAD]\b`)
+)
+
+// registerLexers registers custom chroma lexers for mainframe languages and outputs (used by fancy)
+func registerLexers() {
+	lexers.Register(chroma.MustNewLexer(
+		&chroma.Config{
+			Name:      "JCL",
+			Aliases:   []string{"jcl"},
+			Filenames: []string{"*.jcl", "*.cntl"},
+			EnsureNL:  true,
+		},
+		chroma.Rules{
+			"root": {
+				lexRule(`^//\*.*\n`, chroma.CommentSingle, nil),
+				lexRule(`^/\*.*\n`, chroma.CommentPreproc, nil),
+				lexRule(`^(//)([\w#@$.]*)(\s+)(JOB|EXEC|DD|PROC|PEND|SET|IF|THEN|ELSE|ENDIF|INCLUDE|JCLLIB|OUTPUT|CNTL|ENDCNTL|EXPORT)\b(\s*)`,
+					chroma.ByGroups(chroma.Punctuation, chroma.NameLabel, chroma.Text, chroma.Keyword, chroma.Text), chroma.Push("operands")),
+				lexRule(`^//\s*\n`, chroma.Punctuation, nil),
+				lexRule(`^(//)(\s+)`, chroma.ByGroups(chroma.Punctuation, chroma.Text), chroma.Push("operands")),
+				lexRule(`.*\n`, chroma.Text, nil),
+			},
+			"operands": {
+				lexRule(`\n`, chroma.Text, chroma.Pop(1)),
+				lexRule(`(\s+)(.*)`, chroma.ByGroups(chroma.Text, chroma.Comment), nil),
+				lexRule(`'[^']*'`, chroma.LiteralString, nil),
+				lexRule(`([\w#@$]+)(=)`, chroma.ByGroups(chroma.NameAttribute, chroma.Operator), nil),
+				lexRule(`&&?[\w#@$]+\.?`, chroma.NameVariable, nil),
+				lexRule(`\*\.[\w#@$.]+`, chroma.NameVariable, nil),
+				lexRule(`\d+\b`, chroma.LiteralNumber, nil),
+				lexRule(`[(),]`, chroma.Punctuation, nil),
+				lexRule(`[^\s'=(),&]+`, chroma.Text, nil),
+			},
+		},
+	).SetAnalyser(func(text string) float32 {
+		if jclJobRe.MatchString(text) {
+			return 1.0
+		}
+		if jclStmtRe.MatchString(text) {
+			return 0.8
+		}
+		return 0.0
+	}))
+
+	lexers.Register(chroma.MustNewLexer(
+		&chroma.Config{
+			Name:            "REXX (z/OS)",
+			Aliases:         []string{"zrexx", "tso-rexx"},
+			Filenames:       []string{"*.exec"},
+			NotMultiline:    true,
+			CaseInsensitive: true,
+		},
+		chroma.Rules{
+			"root": {
+				lexRule(`\s+`, chroma.TextWhitespace, nil),
+				lexRule(`/\*`, chroma.CommentMultiline, chroma.Push("comment")),
+				lexRule(`"[^"]*"`, chroma.LiteralString, nil),
+				lexRule(`'[^']*'`, chroma.LiteralString, nil),
+				lexRule(`(address)(\s+)(tso|ispexec|isredit|mvs|syscall|sh|console|link|linkmvs|attach)\b`,
+					chroma.ByGroups(chroma.KeywordReserved, chroma.TextWhitespace, chroma.NameNamespace), nil),
+				lexRule(`[0-9]+(\.[0-9]+)?(e[+-]?[0-9])?`, chroma.LiteralNumber, nil),
+				lexRule(`([a-z_]\w*)(\s*)(:)(\s*)(procedure)\b`, chroma.ByGroups(chroma.NameFunction, chroma.TextWhitespace, chroma.Operator, chroma.TextWhitespace, chroma.KeywordDeclaration), nil),
+				lexRule(`([a-z_]\w*)(\s*)(:)`, chroma.ByGroups(chroma.NameLabel, chroma.TextWhitespace, chroma.Operator), nil),
+				lexRule(`(outtrap|listdsi|msg|mvsvar|prompt|setlang|storage|sysdsn|sysvar|sysexec|sysisp)(\s*)(\()`,
+					chroma.ByGroups(chroma.NameBuiltin, chroma.TextWhitespace, chroma.Operator), nil),
+				lexRule(`(abbrev|abs|address|arg|bitand|bitor|bitxor|c2d|c2x|center|compare|condition|copies|d2c|d2x|datatype|date|delstr|delword|digits|errortext|format|insert|lastpos|left|length|max|min|overlay|pos|queued|random|reverse|right|sign|sourceline|space|strip|substr|subword|symbol|time|trace|translate|trunc|value|verify|word|wordindex|wordlength|wordpos|words|x2c|x2d|xrange)(\s*)(\()`,
+					chroma.ByGroups(chroma.NameBuiltin, chroma.TextWhitespace, chroma.Operator), nil),
+				lexRule(`(address|arg|by|call|do|drop|else|end|exit|execio|for|forever|if|interpret|iterate|leave|nop|numeric|off|on|options|otherwise|parse|pull|push|queue|return|say|select|signal|to|then|trace|until|upper|var|when|while|with)\b`, chroma.KeywordReserved, nil),
+				lexRule(`(-|//|/|\(|\)|\*\*|\*|\\<<|\\<|\\==|\\=|\\>>|\\>|\\|\|\||\||&&|&|%|\+|<<=|<<|<=|<>|<|==|=|><|>=|>>=|>>|>|¬<<|¬<|¬==|¬=|¬>>|¬>|¬|\.|,|;)`, chroma.Operator, nil),
+				lexRule(`[a-z_@#$!?][\w@#$!?.]*`, chroma.Text, nil),
+			},
+			"comment": {
+				lexRule(`[^*]+`, chroma.CommentMultiline, nil),
+				lexRule(`\*/`, chroma.CommentMultiline, chroma.Pop(1)),
+				lexRule(`\*`, chroma.CommentMultiline, nil),
+			},
+		},
+	).SetAnalyser(func(text string) float32 {
+		// REXX exec on z/OS has to start with comment containing REXX word
+		if rexxHeaderRe.MatchString(text) {
+			return 1.0
+		}
+		return 0.0
+	}))
+
+	lexers.Register(chroma.MustNewLexer(
+		&chroma.Config{
+			Name:     "z/OS syslog",
+			Aliases:  []string{"zsyslog", "syslog-zos"},
+			EnsureNL: true,
+		},
+		chroma.Rules{
+			"root": {
+				lexRule(`\d{2}:\d{2}:\d{2}(\.\d{2})?`, chroma.LiteralDate, nil),
+				lexRule(`\b\d{5}\b`, chroma.LiteralDate, nil),
+				lexRule(`\b(JOB|STC|TSU)\d{5}\b|\b[JST]\d{7}\b`, chroma.NameVariable, nil),
+				lexRule(`\b[A-Z$#@]{3,8}\d{3,5}[EA]\b`, chroma.Error, nil),
+				lexRule(`\b[A-Z$#@]{3,8}\d{3,5}[WD]\b`, chroma.NameAttribute, nil),
+				lexRule(`\b[A-Z$#@]{3,8}\d{3,5}I\b`, chroma.NameTag, nil),
+				lexRule(`\bABEND(=|\s+)?[SU]?[0-9A-F]{3,4}\b|\bABEND(ED)?\b`, chroma.Error, nil),
+				lexRule(`\b(RC|COND CODE|CC)(=|\s+)?\d+\b`, chroma.LiteralNumber, nil),
+				lexRule(`'[^'\n]*'`, chroma.LiteralString, nil),
+				lexRule(`\s+`, chroma.Text, nil),
+				lexRule(`[^\s']+`, chroma.Text, nil),
+				lexRule(`'`, chroma.Text, nil),
+			},
+		},
+	).SetAnalyser(func(text string) float32 {
+		if syslogLineRe.MatchString(text) {
+			return 0.9
+		}
+		if syslogMsgRe.MatchString(text) {
+			return 0.5
+		}
+		return 0.0
+	}))
+}
+
+// lexRule creates rule for chroma lexer
+func lexRule(pattern string, emitter chroma.Emitter, mutator chroma.Mutator) chroma.Rule {
+	return chroma.Rule{Pattern: pattern, Type: emitter, Mutator: mutator}
+}
@@ -0,0 +1,190 @@
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// mouseDrag is a border between two items of the layout split which is dragged by mouse
+type mouseDrag struct {
+	split *LayoutNode
+	idx   int // index of visible item after the border
+}
+
+const (
+	mouseView   = "mouse" // invisible view under all the views (to get mouse events on borders)
+	wheelScroll = 3
+)
+
+var drag *mouseDrag
+
+// layoutMouse setup invisible view under all the views, so clicks on borders between views are received
+func layoutMouse(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	v, err := g.SetView(mouseView, -1, -1, maxX, maxY, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) {
+			return fmt.Errorf("view %v: %v", mouseView, err)
+		}
+		g.SetViewOnBottom(mouseView)
+	}
+	v.Visible = false
+	v.Frame = false
+	return nil
+}
+
+// Mouse keybinds (click, drag and wheel)
+func keybindsMouse(g *gocui.Gui) {
+	if err := setKeybinding(g, "", gocui.MouseLeft, gocui.ModNone, "select view, switch workspace or start resizing on border", mouseClick); err != nil {
+		log.Panicln(err)
+	}
+	if err := setKeybinding(g, "", gocui.MouseRelease, gocui.ModNone, "finish resizing", mouseRelease); err != nil {
+		log.Panicln(err)
+	}
+	// mouse motion is received as event without key
+	if err := setKeybinding(g, "", gocui.Key(0), gocui.ModNone, "resize views by dragging border", mouseMove); err != nil {
+		log.Panicln(err)
+	}
+	if err := setKeybinding(g, "", gocui.MouseWheelUp, gocui.ModNone, "scroll view under mouse", func(g *gocui.Gui, v *gocui.View) error {
+		return scrollView(mouseScrollView(v), -wheelScroll)
+	}); err != nil {
+		log.Panicln(err)
+	}
+	if err := setKeybinding(g, "", gocui.MouseWheelDown, gocui.ModNone, "scroll view under mouse", func(g *gocui.Gui, v *gocui.View) error {
+		return scrollView(mouseScrollView(v), wheelScroll)
+	}); err != nil {
+		log.Panicln(err)
+	}
+}
+
+// mouseClick selects the view under the mouse, switches workspace on tab bar or starts dragging of the border
+func mouseClick(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	mx, my := g.MousePosition()
+	switch name := v.Name(); {
+	case name == mouseView:
+		if zoomedView == "" && layoutRoot != nil {
+			drag = layoutRoot.border(mx, my)
+		}
+	case name == tabsView:
+		pos := 0
+		for _, wsp := range workspaces {
+			w := len([]rune(tabLabel(wsp)))
+			if mx >= pos && mx < pos+w {
+				return switchWorkspace(g, wsp.name)
+			}
+			pos += w
+		}
+	case name == cmdView || name == cmdPrompt || name == cmdPromptPS1:
+		// console keeps the focus
+	default:
+		if ws := getWidgetStack(strings.TrimSuffix(name, "-header")); ws != nil {
+			if wc := getConsoleWidget(); wc != nil && !wc.IsHidden() {
+				// select after console is closed
+				wc.lastView = ws.name
+				return nil
+			}
+			g.SetCurrentView(ws.name)
+			if zoomedView != "" {
+				zoomedView = ws.name
+			}
+		} else if _, ok := getWidgetManager(name).(*WidgetFloaty); ok {
+			g.SetCurrentView(name)
+		}
+	}
+	return nil
+}
+
+// mouseMove resizes views when border is dragged
+func mouseMove(g *gocui.Gui, v *gocui.View) error {
+	if drag != nil {
+		drag.resize(g.MousePosition())
+	}
+	return nil
+}
+
+// mouseRelease finishes dragging of the border
+func mouseRelease(g *gocui.Gui, v *gocui.View) error {
+	if drag != nil {
+		drag.resize(g.MousePosition())
+		drag = nil
+	}
+	return nil
+}
+
+// mouseScrollView returns view which is scrolled by mouse wheel (stack, floaty or console output)
+func mouseScrollView(v *gocui.View) *gocui.View {
+	if v == nil {
+		return nil
+	}
+	name := v.Name()
+	if ws := getWidgetStack(strings.TrimSuffix(name, "-header")); ws != nil {
+		return ws.gview
+	}
+	if _, ok := getWidgetManager(name).(*WidgetFloaty); ok || name == cmdView {
+		return v
+	}
+	return nil
+}
+
+// border finds split and item which has border at the position
+func (n *LayoutNode) border(x, y int) *mouseDrag {
+	if n.View != "" {
+		return nil
+	}
+	items := n.visibleItems()
+	for i := 1; i < len(items); i++ {
+		r := items[i].rect
+		if n.Split == "columns" && x == r.x0 && y >= r.y0 && y <= r.y1 {
+			return &mouseDrag{split: n, idx: i}
+		}
+		if n.Split != "columns" && y == r.y0 && x >= r.x0 && x <= r.x1 {
+			return &mouseDrag{split: n, idx: i}
+		}
+	}
+	for _, it := range items {
+		if d := it.border(x, y); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// resize items around the dragged border, so the border is on the mouse position.
+// Weights of all the items in the split are set to their size on the screen (view size is updated).
+func (d *mouseDrag) resize(x, y int) {
+	items := d.split.visibleItems()
+	if d.idx >= len(items) {
+		return
+	}
+	prev, next := items[d.idx-1], items[d.idx]
+	pos, from, to := y, prev.rect.y0, next.rect.y1
+	if d.split.Split == "columns" {
+		pos, from, to = x, prev.rect.x0, next.rect.x1
+	}
+	// keep at least one line for content
+	if pos < from+2 {
+		pos = from + 2
+	}
+	if pos > to-2 {
+		pos = to - 2
+	}
+	if pos <= from || pos >= to {
+		return
+	}
+	for _, it := range items {
+		if d.split.Split == "columns" {
+			it.setWeight(it.rect.x1 - it.rect.x0)
+		} else {
+			it.setWeight(it.rect.y1 - it.rect.y0)
+		}
+	}
+	prev.setWeight(pos - from)
+	next.setWeight(to - pos)
+}
@@ -0,0 +1,211 @@
+package zterm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// splitPipes splits command line by pipes (`|` which is not in quotes and is not `||`)
+func splitPipes(line string) []string {
+	parts := []string{}
+	var quote rune
+	start := 0
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && quote != '\'':
+			i++
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case quote == r:
+			quote = 0
+		case quote == 0 && r == '|':
+			if i+1 < len(runes) && runes[i+1] == '|' {
+				i++
+				continue
+			}
+			parts = append(parts, string(runes[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// splitPipeline splits remote command into the part executed on remote server and local stages
+// (like `zsyslog | local grep -v IEF | sort`). It returns false when there is no local stage.
+func splitPipeline(command string) (string, []string, bool) {
+	parts := splitPipes(command)
+	for i := 1; i < len(parts); i++ {
+		first := strings.TrimSpace(parts[i])
+		if first != "local" && !strings.HasPrefix(first, "local ") {
+			continue
+		}
+		stages := []string{strings.TrimSpace(strings.TrimPrefix(first, "local"))}
+		for _, p := range parts[i+1:] {
+			stages = append(stages, strings.TrimSpace(p))
+		}
+		for _, st := range stages {
+			if st == "" {
+				return command, nil, false
+			}
+		}
+		return strings.TrimSpace(strings.Join(parts[:i], "|")), stages, true
+	}
+	return command, nil, false
+}
+
+// stageError returns error of pipeline stage with its error output
+func stageError(stage string, err error, stderr string) error {
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("%s: %w: %s", stage, err, msg)
+	}
+	return fmt.Errorf("%s: %w", stage, err)
+}
+
+// cmdPipeline executes command on remote server and its output is processed by local commands (stages) before
+// it's displayed in the widget. Error output of the stages is displayed only when the stage fails.
+func cmdPipeline(widget Widgeter, remote string, stages []string, opts *jobOptions) error {
+	if sshConn == nil {
+		return errors.New("SSH connection not created! Adjust your configuration")
+	}
+	ctx, cancel := opts.context()
+
+	// local stages (output of one stage is input of the next one)
+	remoteR, remoteW, err := os.Pipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	cmds := []*exec.Cmd{}
+	stderrs := []*bytes.Buffer{}
+	// abort kills already started stages and waits for them (so they don't stay as zombies)
+	abort := func(err error, files ...*os.File) error {
+		cancel()
+		for _, f := range files {
+			f.Close()
+		}
+		for _, c := range cmds {
+			c.Wait()
+		}
+		return err
+	}
+	var in *os.File = remoteR
+	var outPipe *os.File
+	for _, st := range stages {
+		c, err := opts.command(ctx, st)
+		if err != nil {
+			return abort(stageError("local "+st, err, ""), remoteW, in)
+		}
+		c.Stdin = in
+		stderr := &bytes.Buffer{}
+		c.Stderr = stderr
+		r, w, err := os.Pipe()
+		if err == nil {
+			c.Stdout = w
+			err = startCommand(ctx, c)
+			w.Close() // stage has its own copy
+		}
+		in.Close() // stage has its own copy
+		if err != nil {
+			return abort(stageError("local "+st, err, ""), remoteW, r)
+		}
+		cmds = append(cmds, c)
+		stderrs = append(stderrs, stderr)
+		in, outPipe = r, r
+	}
+
+	// remote command (output goes into the first stage)
+	remoteErr := &bytes.Buffer{}
+	job, err := startSSHJob(ctx, remote, opts, remoteW, remoteErr, false)
+	if err != nil {
+		return abort(err, remoteW, outPipe)
+	}
+
+	// prepare communication channel RecvConn
+	comch := NewRecvConn()
+
+	// monitor for cancel (or timeout) and stop the remote command (stages are killed by the context)
+	job.stopOn(comch.signal)
+
+	// remote command ends, so the first stage gets end of input
+	var remoteWait error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		remoteWait = job.wait()
+		remoteW.Close()
+	}()
+
+	// read output of the last stage and wait for all of them
+	go func() {
+		defer close(comch.err)
+		defer outPipe.Close()
+
+		killed := false
+		scan := bufio.NewScanner(outPipe)
+	read:
+		for scan.Scan() {
+			select {
+			case <-comch.signal:
+				// killing signal
+				killed = true
+				break read
+			case comch.outchan <- scan.Text():
+			}
+		}
+		close(comch.outchan)
+		if killed {
+			// kill all the stages (session is closed by the monitor) and stop their output
+			cancel()
+			outPipe.Close()
+		}
+
+		// always wait for the session and all the stages (so there are no zombie processes)
+		errs := []error{}
+		wg.Wait()
+		if remoteWait != nil {
+			errs = append(errs, stageError("remote "+remote, remoteWait, remoteErr.String()))
+		}
+		for i, c := range cmds {
+			if err := c.Wait(); err != nil {
+				errs = append(errs, stageError("local "+stages[i], err, stderrs[i].String()))
+			}
+		}
+		if err := opts.timeoutError(ctx, nil); err != nil {
+			// stages were killed, so their errors are not important
+			errs = []error{err}
+		}
+		cancel()
+		if killed {
+			return
+		}
+		for _, err := range errs {
+			select {
+			case <-comch.signal:
+				// skip passing error (it's already killed)
+				return
+			case comch.err <- err:
+			}
+		}
+	}()
+
+	connectWidgetOuput(widget, comch)
+
+	return nil
+}
+
+// cmdRemote executes command on remote server, with local stages when the command has them (`cmd | local stage`)
+func cmdRemote(widget Widgeter, command string, opts *jobOptions) error {
+	if remote, stages, ok := splitPipeline(command); ok {
+		return cmdPipeline(widget, remote, stages, opts)
+	}
+	return cmdSSHJob(widget, command, opts)
+}
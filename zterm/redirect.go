@@ -0,0 +1,188 @@
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// redirect is a redirection of command output into the view (`> view:name` replaces content, `>> view:name` appends)
+type redirect struct {
+	view      string
+	appendOut bool
+}
+
+const viewPrefix = "view:" // prefix of the redirection target
+
+// appendWidget is a widget wrapper which keeps the content of the widget (output is appended)
+type appendWidget struct {
+	Widgeter
+}
+
+// Clear keeps the content (output is appended to it)
+func (aw *appendWidget) Clear() {
+}
+
+// parseRedirect returns the command without redirection at the end of the line (`cmd > view:name`, `cmd >>view:name`).
+// Redirection into other targets (like files) is kept in the command for the shell.
+func parseRedirect(cl *cmdLine) (string, *redirect, error) {
+	n := len(cl.args)
+	op, target, start := "", "", 0
+	switch {
+	case n >= 3 && (cl.args[n-2] == ">" || cl.args[n-2] == ">>") && cl.raw[cl.offs[n-2]] == '>':
+		op, target, start = cl.args[n-2], cl.args[n-1], cl.offs[n-2]
+	case n >= 2 && cl.raw[cl.offs[n-1]] == '>':
+		target = strings.TrimLeft(cl.args[n-1], ">")
+		op, start = cl.args[n-1][:len(cl.args[n-1])-len(target)], cl.offs[n-1]
+	}
+	if (op != ">" && op != ">>") || !strings.HasPrefix(target, viewPrefix) {
+		return cl.raw, nil, nil
+	}
+	r := &redirect{view: strings.TrimPrefix(target, viewPrefix), appendOut: op == ">>"}
+	if r.view == "" {
+		return "", nil, errors.New("redirect: requires view name (like `> view:name`)")
+	}
+	return strings.TrimSpace(cl.raw[:start]), r, nil
+}
+
+// hasOutput checks if the command has output (local, remote or fancy command), other console commands don't
+func hasOutput(name string) bool {
+	c, ok := cmdRegistry[name]
+	return !ok || c.local || c.name == "remote" || c.name == "fancy"
+}
+
+// runRedirect executes command with output in the view (view is created if it doesn't exist)
+func runRedirect(wgm Widgeter, cmd string, r *redirect) error {
+	if cl, err := tokenize(cmd); err != nil || len(cl.args) == 0 {
+		return errors.New("redirect: requires command")
+	} else if !hasOutput(cl.args[0]) {
+		return fmt.Errorf("redirect: output of console command %s can't be redirected", cl.args[0])
+	}
+	ws := getWidgetStack(r.view)
+	if ws == nil {
+		layoutAddView(r.view)
+		addView(r.view)
+		ws = getWidgetStack(r.view)
+	} else if ws.Fun != nil {
+		return fmt.Errorf("redirect: view '%s' has attached command (detach it first)", r.view)
+	}
+	var out Widgeter = ws
+	if ws.render != nil {
+		out = ws.render
+	}
+	if r.appendOut {
+		out = &appendWidget{out}
+	} else {
+		out.Clear()
+	}
+	if cw, ok := wgm.(*chainWidget); ok {
+		// chain waits for the command in the view
+		rcw := &chainWidget{Widgeter: out, sources: cw.sources}
+		defer func() {
+			cw.conn = rcw.conn
+		}()
+		out = rcw
+	}
+	if err := runCommand(out, cmd); err != nil {
+		return err
+	}
+	return info("output redirected to view '%s'", r.view)
+}
+
+// lastCommand returns the last command executed in the console (except `pin`)
+func (wc *WidgetConsole) lastCommand() string {
+	for i := len(wc.cmdHistory) - 1; i >= 0; i-- {
+		if cl, err := tokenize(wc.cmdHistory[i]); err == nil && len(cl.args) > 0 && cl.args[0] != "pin" {
+			return wc.cmdHistory[i]
+		}
+	}
+	return ""
+}
+
+// pinCommand attaches the last console command to the view (new view is created if name isn't specified).
+// Command attached to the view is replaced only with `-f` option.
+func pinCommand(args []string) error {
+	force := len(args) > 0 && args[0] == "-f"
+	if force {
+		args = args[1:]
+	}
+	last := ""
+	if wc := getConsoleWidget(); wc != nil {
+		last = wc.lastCommand()
+	}
+	if last == "" {
+		return errors.New("pin: no command to pin")
+	}
+	steps, err := expandCommand(last)
+	if err != nil {
+		return err
+	}
+	if len(steps) != 1 {
+		return fmt.Errorf("pin: chained commands can't be pinned (%s)", last)
+	}
+	cl, err := tokenize(steps[0].cmd)
+	if err != nil {
+		return fmt.Errorf("parse: %v", err)
+	}
+	cmd, r, err := parseRedirect(cl)
+	if err != nil {
+		return err
+	}
+	if cl, err = tokenize(cmd); err != nil || len(cl.args) == 0 {
+		return fmt.Errorf("pin: command can't be pinned (%s)", last)
+	}
+	if !hasOutput(cl.args[0]) {
+		return fmt.Errorf("pin: console command %s can't be pinned", cl.args[0])
+	}
+
+	var vname string
+	switch {
+	case len(args) > 0:
+		vname = args[0]
+	case r != nil:
+		vname = r.view
+	default:
+		vname = pinViewName(cl)
+	}
+	ws := getWidgetStack(vname)
+	if ws == nil {
+		layoutAddView(vname)
+		addView(vname)
+		ws = getWidgetStack(vname)
+	} else if ws.Fun != nil && !force {
+		return fmt.Errorf("pin: view '%s' has attached command (detach it first or use `pin -f`)", vname)
+	}
+	ws.StopFun()
+	resumed := ws.paused
+	// pinned command runs (even if the view was paused), it's started by SetupFun
+	ws.markPaused(false)
+	ws.SetupFun(cmd)
+	if resumed {
+		return info("command `%s` pinned to view '%s' (view resumed)", cmd, vname)
+	}
+	return info("command `%s` pinned to view '%s'", cmd, vname)
+}
+
+var viewNameRe = regexp.MustCompile(`[^\w-]+`)
+
+// pinViewName returns name for new view by the command (like `ps` or `zsyslog` for `remote zsyslog`)
+func pinViewName(cl *cmdLine) string {
+	name := cl.args[0]
+	if (name == "remote" || strings.HasPrefix(name, "fancy")) && len(cl.args) > 1 {
+		name = cl.args[1]
+		if name == "remote" && len(cl.args) > 2 {
+			name = cl.args[2]
+		}
+	}
+	name = viewNameRe.ReplaceAllString(filepath.Base(name), "")
+	if name == "" {
+		name = "pinned"
+	}
+	vname := name
+	for i := 2; getWidgetStack(vname) != nil; i++ {
+		vname = fmt.Sprintf("%s-%d", name, i)
+	}
+	return vname
+}
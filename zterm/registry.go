@@ -0,0 +1,369 @@
+package zterm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// consoleCommand describes console command (arguments, help and completion of arguments)
+type consoleCommand struct {
+	name     string
+	args     string      // usage of arguments (like `<view-name> [size]`)
+	help     string      // short description
+	options  []cmdOption // options (subcommands) of the command
+	examples []string
+	local    bool // command is executed by local shell
+	passRest bool // rest of the line is passed to shell or server (so `;` and `&&` in it don't split the chain)
+	// run executes the command (commands without it are executed by local shell)
+	run func(wgm Widgeter, cl *cmdLine) error
+	// complete returns candidates for the last argument (args are without command name, last one is being typed)
+	complete func(args []string) []string
+}
+
+// cmdOption is an option (subcommand) of the console command
+type cmdOption struct {
+	name string
+	args string
+	help string
+}
+
+// cmdRegistry contains all console commands by name
+var cmdRegistry = map[string]*consoleCommand{}
+
+// viewOptions are config options of `view` command
+var viewOptions = []cmdOption{
+	{"hi-word", "<word>", "highlight word"},
+	{"hi-line", "<word>", "highlight line which contains word"},
+	{"hi-remove", "<word>", "remove highlight for specific word"},
+	{"refresh", "[<duration>|default]", "set refresh interval (like 30s or 1m, number is in seconds)"},
+	{"schedule", "[<cron>|off]", "refresh only when time matches cron-like schedule (minute hour day month weekday)"},
+	{"paused", "[on|off]", "pause or resume refreshing"},
+	{"alert", "<regex>", "notify when new output line matches regex (more options in config file)"},
+	{"alert-remove", "<regex>", "remove alert rule"},
+	{"cwd", "[<dir>|default]", "working directory of the job"},
+	{"env", "<name>=<value>", "set environment variable of the job (empty value removes it)"},
+	{"shell", "[sh|bash|zsh|none|default]", "shell of local job (none executes the command directly)"},
+	{"timeout", "[<duration>|default]", "kill the job when it runs longer (like 30s or 1m)"},
+	{"watch-diff", "[on|off|<fade>]", "highlight changes between refreshes (fade keeps them marked for <fade> refreshes)"},
+	{"strip-ansi", "[on|off]", "display output without ANSI colors"},
+	{"render", "[text|table|graph]", "display output as text, table or graph"},
+	{"graph-regex", "<regex>", "extract graph values by regex (each capture group is a series)"},
+	{"graph-json", "<path>", "extract graph values by json path (like cpu.usage,items.0.value)"},
+	{"graph-style", "block|braille|line", "graph style"},
+	{"table-sort", "<column>", "sort table by column (prefix with - for descending order)"},
+	{"table-hide", "<column>", "hide table column"},
+	{"table-show", "<column>", "show hidden table column"},
+	{"table-format", "auto|whitespace|csv|json", "table format"},
+}
+
+func init() {
+	registerCommands(
+		&consoleCommand{name: "addview", run: runAddView, args: "<view-name>", help: "add new empty view to the layout",
+			examples: []string{"addview joblog"}},
+		&consoleCommand{name: "alias", run: runAlias, args: "[name[=command]]", help: "list aliases, display or define alias (saved by savecfg)",
+			examples: []string{"alias jl='remote jls'", "alias jl"},
+			complete: completeArgs(aliasNames)},
+		&consoleCommand{name: "attach", run: runAttach, passRest: true, args: "<view-name> <command>", help: "run command periodically in the view",
+			examples: []string{"attach joblog remote jls", "attach logs tail -n 20 /var/log/messages"},
+			complete: completeArgs(viewNames)},
+		&consoleCommand{name: "code", run: runCode, passRest: true, args: "[file]", help: "open file in vscode",
+			complete: completeLocalPaths},
+		&consoleCommand{name: "detach", run: runDetach, args: "<view-name>", help: "stop and remove command from the view",
+			complete: completeArgs(viewNames)},
+		&consoleCommand{name: "error", run: runError, help: "fail (for testing)"},
+		&consoleCommand{name: "exit", run: runExit, help: "exit zterm"},
+		&consoleCommand{name: "fancy", passRest: true, args: "<command>", help: "run command and highlight its output (use fancy:<lexer> to choose syntax)",
+			examples: []string{"fancy cat main.go", "fancy:yaml remote cat config.yaml"}},
+		&consoleCommand{name: "help", run: runHelp, args: "[command]", help: "display help for commands",
+			complete: completeArgs(commandNames)},
+		&consoleCommand{name: "hide", run: runHideShow, args: "<view-name>", help: "hide the view (command keeps running)",
+			complete: completeArgs(viewNames)},
+		&consoleCommand{name: "macro", run: runMacro, args: "[name]", help: "list macros or display macro (macros are defined in config)",
+			complete: completeArgs(aliasNames)},
+		&consoleCommand{name: "move", run: runMove, args: "<view-name> up|down|left|right|<pos>", help: "move the view in the layout",
+			examples: []string{"move syslog up", "move syslog 1"},
+			complete: completeArgs(viewNames, words("up", "down", "left", "right"))},
+		&consoleCommand{name: "pin", run: runPin, args: "[-f] [view-name]", help: "attach the last console command to the view (new view is created by default)",
+			examples: []string{"pin", "pin procs", "pin -f procs"},
+			complete: completeArgs(viewNames)},
+		&consoleCommand{name: "remote", run: runRemote, passRest: true, args: "<command> [| local <command>]", help: "run command on remote server (output can be processed by local commands)",
+			examples: []string{"remote jls", "remote cat /etc/profile"},
+			complete: completeRemoteArgs},
+		&consoleCommand{name: "rename", run: runRename, args: "<view-name> <new-view-name>", help: "rename the view",
+			complete: completeArgs(viewNames)},
+		&consoleCommand{name: "resize", run: runResize, args: "<view-name> [size]", help: "change size of the view by size (default 1, negative shrinks)",
+			examples: []string{"resize joblog 5", "resize joblog -2"},
+			complete: completeArgs(viewNames)},
+		&consoleCommand{name: "rmview", run: runRmView, args: "<view-name>", help: "remove the view",
+			complete: completeArgs(viewNames)},
+		&consoleCommand{name: "rvim", run: runRVim, args: "<file>", help: "edit remote file or dataset in vim",
+			examples: []string{"rvim /etc/profile", "rvim //'USER.JCL(MYJOB)'"},
+			complete: completeRemotePaths},
+		&consoleCommand{name: "savecfg", run: runSaveCfg, help: "save views, layout and workspaces into config file"},
+		&consoleCommand{name: "show", run: runHideShow, args: "<view-name>", help: "display hidden view",
+			complete: completeArgs(viewNames)},
+		&consoleCommand{name: "status", run: runStatus, args: "[view-name...]", help: "list job status of views (result, duration, last run and success, failures)",
+			complete: completeArgs(viewNames)},
+		&consoleCommand{name: "source", run: runSource, args: "[-c|--continue-on-error] <file>", help: "execute console commands from the file (stops on error by default)",
+			examples: []string{"source ~/.zterm/setup.zt", "source -c jobs.zt"},
+			complete: completeLocalPaths},
+		&consoleCommand{name: "split", run: runSplit, args: "<view-name> <new-view-name> [columns|rows]", help: "split the view and put new (or existing) view next to it",
+			examples: []string{"split joblog syslog", "split joblog syslog rows"},
+			complete: completeArgs(viewNames, viewNames, words("columns", "rows"))},
+		&consoleCommand{name: "swap", run: runSwap, args: "<view-name> <view-name>", help: "swap positions of two views",
+			complete: completeArgs(viewNames, viewNames)},
+		&consoleCommand{name: "unalias", run: runUnalias, args: "<name>", help: "remove alias",
+			complete: completeArgs(aliasNames)},
+		&consoleCommand{name: "view", run: runView, args: "<view-name> <config> [value]", help: "configure the view", options: viewOptions,
+			examples: []string{`view joblog hi-line "ABEND S0C4"`, "view joblog render table", "view joblog table-sort -CPU", "view syslog schedule * 8-17 * * mon-fri"},
+			complete: completeView},
+		&consoleCommand{name: "vim", run: runVim, args: "<file>", help: "edit local file in vim",
+			complete: completeLocalPaths},
+		&consoleCommand{name: "workspace", run: runWorkspace, args: "[name]", help: "list workspaces or switch to workspace (created if doesn't exist)",
+			complete: completeArgs(workspaceNames)},
+
+		// local commands
+		&consoleCommand{name: "cd", args: "[dir]", help: "change local directory", local: true,
+			complete: completeLocalPaths},
+		&consoleCommand{name: "ls", args: "[file...]", help: "list local directory", local: true,
+			complete: completeLocalPaths},
+		&consoleCommand{name: "pwd", help: "print local directory", local: true},
+		&consoleCommand{name: "which", args: "<command>", help: "locate local command", local: true},
+		&consoleCommand{name: "whoami", help: "print local user", local: true},
+	)
+}
+
+// registerCommands adds commands into the registry
+func registerCommands(cmds ...*consoleCommand) {
+	for _, c := range cmds {
+		cmdRegistry[c.name] = c
+	}
+}
+
+// usage returns command with its arguments
+func (c *consoleCommand) usage() string {
+	return strings.TrimSpace(c.name + " " + c.args)
+}
+
+// minArgs returns number of required arguments (arguments in usage which are not in brackets)
+func (c *consoleCommand) minArgs() int {
+	n, depth, inArg := 0, 0, false
+	for _, r := range c.args {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case r == ' ':
+			inArg = false
+		case depth == 0 && !inArg:
+			inArg = true
+			n++
+		}
+	}
+	return n
+}
+
+// usageError returns error of the command with missing arguments (with usage and options)
+func (c *consoleCommand) usageError() error {
+	if len(c.options) > 0 {
+		return fmt.Errorf("missing arguments - usage: %s\n\noptions:\n%s", c.usage(), c.optionsHelp())
+	}
+	return fmt.Errorf("missing arguments - usage: %s", c.usage())
+}
+
+// optionsHelp returns description of the command options (one per line)
+func (c *consoleCommand) optionsHelp() string {
+	width := 0
+	for _, o := range c.options {
+		if w := len(o.name + " " + o.args); w > width {
+			width = w
+		}
+	}
+	lines := []string{}
+	for _, o := range c.options {
+		lines = append(lines, fmt.Sprintf(" %-*s - %s", width, o.name+" "+o.args, o.help))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commandHelp returns help for the command (usage, options and examples)
+func (c *consoleCommand) commandHelp() string {
+	help := fmt.Sprintf("%v - %v\n\nUsage: %v", c.name, c.help, c.usage())
+	if c.local {
+		help += "\n\nLocal command (executed by shell)."
+	}
+	if len(c.options) > 0 {
+		help += "\n\nOptions:\n" + c.optionsHelp()
+	}
+	if len(c.examples) > 0 {
+		help += "\n\nExamples:"
+		for _, e := range c.examples {
+			help += "\n " + e
+		}
+	}
+	return help
+}
+
+// commandsHelp returns list of all commands with short description
+func commandsHelp() string {
+	width := 0
+	for _, c := range cmdRegistry {
+		if w := len(c.usage()); w > width {
+			width = w
+		}
+	}
+	lines := []string{"Console commands (type `help <command>` for details):", ""}
+	local := []string{"", "Local commands (any other command is executed by local shell too):", ""}
+	for _, name := range commandNames() {
+		c := cmdRegistry[name]
+		line := fmt.Sprintf(" %-*s - %s", width, c.usage(), c.help)
+		if c.local {
+			local = append(local, line)
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(append(lines, local...), "\n")
+}
+
+// completeCommand returns candidates for the last argument of the command line (command name or its argument)
+func completeCommand(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	var candidates []string
+	n := len(args)
+	c, ok := cmdRegistry[args[0]]
+	_, alias := config.Aliases[args[0]]
+	_, macro := config.Macros[args[0]]
+	switch {
+	case n > 2 && (args[n-2] == ">" || args[n-2] == ">>"):
+		// redirection into the view
+		for _, name := range viewNames() {
+			candidates = append(candidates, viewPrefix+name)
+		}
+	case n == 1:
+		candidates = append(commandNames(), aliasNames()...)
+	case ok:
+		if c.complete != nil {
+			candidates = c.complete(args[1:])
+		}
+	case alias:
+		candidates = completeAlias(args)
+	case macro:
+		// arguments of macro are not known
+	default:
+		// unknown commands are executed by local shell
+		candidates = completeLocalPaths(args[1:])
+	}
+	last := args[len(args)-1]
+	final := []string{}
+	for _, c := range candidates {
+		if strings.HasPrefix(c, last) {
+			final = append(final, c)
+		} else if isDsn(c) && strings.HasPrefix(strings.ReplaceAll(c, "'", ""), strings.ToUpper(last)) {
+			// dataset names are typed without closing quote (and in any case)
+			final = append(final, c)
+		}
+	}
+	return final
+}
+
+// completeArgs returns completion which uses candidates by position of the argument
+func completeArgs(positional ...func() []string) func(args []string) []string {
+	return func(args []string) []string {
+		idx := len(args) - 1
+		if idx < 0 || idx >= len(positional) || positional[idx] == nil {
+			return nil
+		}
+		return positional[idx]()
+	}
+}
+
+// words returns candidates which are always the same
+func words(list ...string) func() []string {
+	return func() []string {
+		return list
+	}
+}
+
+// commandNames returns sorted names of all commands
+func commandNames() []string {
+	names := []string{}
+	for name := range cmdRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// viewNames returns sorted names of views from all workspaces
+func viewNames() []string {
+	names := []string{}
+	for _, w := range widgets {
+		if ws, ok := w.(*WidgetStack); ok {
+			names = append(names, ws.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeView returns view names, config options and values of the option for `view` command
+func completeView(args []string) []string {
+	if len(args) != 3 {
+		return completeArgs(viewNames, viewOptionNames)(args)
+	}
+	// highlights of the view can be removed
+	if args[1] == "hi-remove" {
+		words := []string{}
+		if ws := getWidgetStack(args[0]); ws != nil {
+			for hi := range ws.highlight {
+				words = append(words, hi)
+			}
+			sort.Strings(words)
+		}
+		return words
+	}
+	// alert rules of the view can be removed
+	if args[1] == "alert-remove" {
+		rules := []string{}
+		for _, ar := range config.Views[args[0]].Alerts {
+			rules = append(rules, ar.Regex)
+		}
+		return rules
+	}
+	// values listed in option arguments (like `[on|off|<fade>]`)
+	for _, o := range viewOptions {
+		if o.name == args[1] {
+			values := []string{}
+			for _, val := range strings.Split(strings.Trim(o.args, "[]"), "|") {
+				if !strings.HasPrefix(val, "<") {
+					values = append(values, val)
+				}
+			}
+			return values
+		}
+	}
+	return nil
+}
+
+// viewOptionNames returns names of `view` command options
+func viewOptionNames() []string {
+	names := []string{}
+	for _, o := range viewOptions {
+		names = append(names, o.name)
+	}
+	return names
+}
+
+// workspaceNames returns names of workspaces
+func workspaceNames() []string {
+	names := []string{}
+	for _, wsp := range workspaces {
+		names = append(names, wsp.name)
+	}
+	return names
+}
@@ -0,0 +1,152 @@
+package zterm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a cron-like schedule (minute hour day-of-month month day-of-week) of the view refreshes.
+// View refreshes only when the current time matches it (e.g. `* 8-17 * * mon-fri` for business hours).
+type cronSchedule struct {
+	expr   string
+	fields [5]uint64 // allowed values of the fields (bit for every value)
+	dom    bool      // day of month is restricted (not `*`)
+	dow    bool      // day of week is restricted (not `*`)
+}
+
+// cronFields describes fields of the schedule (names are allowed for months and days of week)
+var cronFields = [5]struct {
+	name     string
+	min, max int
+	names    []string
+}{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// parseSchedule parses cron-like schedule (fields can be `*`, values, ranges `a-b`, steps `*/n` and lists `a,b`)
+func parseSchedule(expr string) (*cronSchedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("schedule '%s': requires 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	cs := &cronSchedule{expr: strings.Join(parts, " ")}
+	for i, p := range parts {
+		bits, err := parseCronField(p, i)
+		if err != nil {
+			return nil, fmt.Errorf("schedule '%s': %v", expr, err)
+		}
+		cs.fields[i] = bits
+	}
+	// sunday is 0 or 7
+	if cs.fields[4]&(1<<7) != 0 {
+		cs.fields[4] |= 1
+	}
+	cs.dom = parts[2] != "*"
+	cs.dow = parts[4] != "*"
+	return cs, nil
+}
+
+// parseCronField returns allowed values of the schedule field as bits
+func parseCronField(field string, idx int) (uint64, error) {
+	f := cronFields[idx]
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step in '%s'", f.name, part)
+			}
+			rng, step = part[:i], n
+		}
+		from, to := f.min, f.max
+		if rng != "*" {
+			lo, hi, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = cronValue(lo, idx); err != nil {
+				return 0, err
+			}
+			to = from
+			if isRange {
+				if to, err = cronValue(hi, idx); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// `a/n` means from a to the max
+				to = f.max
+			}
+			if to < from {
+				return 0, fmt.Errorf("%s: invalid range '%s'", f.name, rng)
+			}
+		}
+		for v := from; v <= to; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// cronValue returns value of the schedule field (number or name)
+func cronValue(s string, idx int) (int, error) {
+	f := cronFields[idx]
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: invalid value '%s' (%d-%d)", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// matches checks if the time is in the schedule (when both days are restricted, one of them has to match like in cron)
+func (cs *cronSchedule) matches(t time.Time) bool {
+	has := func(idx, v int) bool {
+		return cs.fields[idx]&(1<<v) != 0
+	}
+	if !has(0, t.Minute()) || !has(1, t.Hour()) || !has(3, int(t.Month())) {
+		return false
+	}
+	dom, dow := has(2, t.Day()), has(4, int(t.Weekday()))
+	if cs.dom && cs.dow {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// String returns the schedule expression
+func (cs *cronSchedule) String() string {
+	return cs.expr
+}
+
+// parseRefresh parses refresh interval (duration like `30s`, `1m` or number of seconds)
+func parseRefresh(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		n, nerr := strconv.ParseFloat(s, 64)
+		if nerr != nil {
+			return 0, fmt.Errorf("refresh: invalid interval '%s' (use duration like 30s or 1m)", s)
+		}
+		d = time.Duration(n * float64(time.Second))
+	}
+	if d < 100*time.Millisecond {
+		return 0, fmt.Errorf("refresh: interval '%s' is too short (minimum is 100ms)", s)
+	}
+	return d, nil
+}
+
+// defaultRefresh returns refresh interval of views without their own (`server.refresh` in seconds, 5s by default)
+func defaultRefresh() time.Duration {
+	if config.Server.Refresh > 0 {
+		return time.Duration(config.Server.Refresh) * time.Second
+	}
+	return 5 * time.Second
+}
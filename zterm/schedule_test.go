@@ -0,0 +1,111 @@
+package zterm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleMatches(t *testing.T) {
+	// 2024-03-04 is monday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, time.March, day, hour, min, 0, 0, time.Local)
+	}
+	tests := []struct {
+		expr  string
+		time  time.Time
+		match bool
+	}{
+		{"* * * * *", at(4, 0, 0), true},
+		{"* 8-17 * * mon-fri", at(4, 8, 0), true},
+		{"* 8-17 * * mon-fri", at(4, 17, 59), true},
+		{"* 8-17 * * mon-fri", at(4, 18, 0), false},
+		{"* 8-17 * * mon-fri", at(9, 12, 0), false},
+		{"*/15 * * * *", at(4, 10, 45), true},
+		{"*/15 * * * *", at(4, 10, 46), false},
+		{"5/20 * * * *", at(4, 10, 25), true},
+		{"5/20 * * * *", at(4, 10, 5), true},
+		{"5/20 * * * *", at(4, 10, 20), false},
+		{"0-10/5 * * * *", at(4, 10, 10), true},
+		{"0-10/5 * * * *", at(4, 10, 15), false},
+		{"0,30 9,18 * * *", at(4, 18, 30), true},
+		{"0,30 9,18 * * *", at(4, 12, 30), false},
+		{"* * * MAR *", at(4, 1, 1), true},
+		{"* * * jan-feb,dec *", at(4, 1, 1), false},
+		// sunday is 0 or 7
+		{"* * * * 7", at(10, 1, 1), true},
+		{"* * * * 0", at(10, 1, 1), true},
+		{"* * * * sun", at(9, 1, 1), false},
+		// restricted day of month and day of week, one of them has to match
+		{"* * 1 * mon", at(4, 1, 1), true},
+		{"* * 1 * mon", at(1, 1, 1), true},
+		{"* * 1 * mon", at(5, 1, 1), false},
+		{"* * 1-7 * *", at(5, 1, 1), true},
+		{"* * 1-3 * *", at(5, 1, 1), false},
+	}
+	for _, tt := range tests {
+		cs, err := parseSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("parseSchedule(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := cs.matches(tt.time); got != tt.match {
+			t.Errorf("schedule %q matches %v = %v, want %v", tt.expr, tt.time.Format("Mon Jan 2 15:04"), got, tt.match)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * fri-mon",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"1, * * * *",
+	} {
+		if _, err := parseSchedule(expr); err == nil {
+			t.Errorf("parseSchedule(%q): expected error", expr)
+		}
+	}
+}
+
+func TestScheduleString(t *testing.T) {
+	cs, err := parseSchedule("  */5   8-17 * *  mon-fri ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cs.String(), "*/5 8-17 * * mon-fri"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestParseRefresh(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Duration
+	}{
+		{"30s", 30 * time.Second},
+		{"1m30s", 90 * time.Second},
+		{"500ms", 500 * time.Millisecond},
+		{"10", 10 * time.Second},
+		{"0.5", 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got, err := parseRefresh(tt.s); err != nil || got != tt.want {
+			t.Errorf("parseRefresh(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+	for _, s := range []string{"", "fast", "50ms", "0", "-5s"} {
+		if _, err := parseRefresh(s); err == nil {
+			t.Errorf("parseRefresh(%q): expected error", s)
+		}
+	}
+}
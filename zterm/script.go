@@ -0,0 +1,144 @@
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// scriptLine is a console command from the script file
+type scriptLine struct {
+	num int // line number in the file
+	cmd string
+}
+
+// readScript reads console commands from the script file (empty lines and comments starting with # are skipped)
+func readScript(file string) ([]scriptLine, error) {
+	if strings.HasPrefix(file, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			file = filepath.Join(home, file[2:])
+		}
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	lines := []scriptLine{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, scriptLine{i + 1, line})
+	}
+	return lines, nil
+}
+
+// cmdSource executes console commands from the script file in background (one by one, like they were typed in console).
+// Script stops at the first failed command, unless keepGoing is set.
+// Script which is already executed (it sources itself, directly or thru other script) is not executed again.
+func cmdSource(wgm Widgeter, file string, keepGoing bool) error {
+	path, err := filepath.Abs(expandHome(file))
+	if err != nil {
+		return fmt.Errorf("source: %v", err)
+	}
+	var sources []string
+	cw, inChain := wgm.(*chainWidget)
+	if inChain {
+		sources = cw.sources
+	}
+	for _, s := range sources {
+		if s == path {
+			return fmt.Errorf("source: %v: script is already executed (recursive source)", file)
+		}
+	}
+	lines, err := readScript(file)
+	if err != nil {
+		return fmt.Errorf("source: %v", err)
+	}
+	conn := NewRecvConn()
+	if inChain {
+		// script is part of the chain (or other script), so the chain waits for its end
+		cw.conn = conn
+		wgm = cw.Widgeter
+	}
+	// copy, so scripts of other chains don't share it
+	sources = append(append([]string{}, sources...), path)
+	go func() {
+		defer conn.Stop()
+		conn.failed = runScript(wgm, file, lines, keepGoing, sources)
+	}()
+	return nil
+}
+
+// runScript executes script commands and returns true when some of them failed
+func runScript(wgm Widgeter, file string, lines []scriptLine, keepGoing bool, sources []string) bool {
+	failed := false
+	for _, line := range lines {
+		gui.UpdateAsync(func(g *gocui.Gui) error {
+			wgm.Print(promptPS1 + line.cmd + "\n")
+			return nil
+		})
+		lineFailed := false
+		if steps, err := expandCommand(line.cmd); err != nil {
+			gui.UpdateAsync(func(g *gocui.Gui) error {
+				wgm.Error(err)
+				return nil
+			})
+			lineFailed = true
+		} else {
+			lineFailed = runChain(wgm, steps, sources)
+		}
+		if lineFailed {
+			failed = true
+			if !keepGoing {
+				gui.UpdateAsync(func(g *gocui.Gui) error {
+					wgm.Error(fmt.Errorf("source: %v:%v: script stopped on error", file, line.num))
+					return nil
+				})
+				return true
+			}
+		}
+	}
+	return failed
+}
+
+// parseSource parses arguments of `source` command (file and continue-on-error option)
+func parseSource(args []string) (string, bool, error) {
+	file, keepGoing := "", false
+	for _, a := range args {
+		switch {
+		case a == "-c" || a == "--continue-on-error":
+			keepGoing = true
+		case file == "":
+			file = a
+		default:
+			return "", false, fmt.Errorf("source: unexpected argument %v", a)
+		}
+	}
+	if file == "" {
+		return "", false, errors.New("source: requires script file")
+	}
+	return file, keepGoing, nil
+}
+
+// runStartupScript executes script file passed by `--script` flag (console is displayed to show its output)
+func runStartupScript(g *gocui.Gui, file string, keepGoing bool) error {
+	wc := getConsoleWidget()
+	if wc == nil {
+		return nil
+	}
+	wc.Enabled = true
+	if err := wc.Layout(g); err != nil {
+		return err
+	}
+	wc.Println(promptPS1 + "source " + quoteArg(file))
+	if err := cmdSource(wc, file, keepGoing); err != nil {
+		wc.Error(err)
+	}
+	return nil
+}
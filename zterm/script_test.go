@@ -0,0 +1,74 @@
+package zterm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadScript(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "setup.zt")
+	script := "# views for the build\naddview build\n\n  attach build make  \n# done\n"
+	if err := os.WriteFile(file, []byte(script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	lines, err := readScript(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []scriptLine{{2, "addview build"}, {4, "attach build make"}}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("readScript = %v, want %v", lines, want)
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		args      []string
+		file      string
+		keepGoing bool
+		fails     bool
+	}{
+		{[]string{"a.zt"}, "a.zt", false, false},
+		{[]string{"-c", "a.zt"}, "a.zt", true, false},
+		{[]string{"a.zt", "--continue-on-error"}, "a.zt", true, false},
+		{[]string{}, "", false, true},
+		{[]string{"-c"}, "", false, true},
+		{[]string{"a.zt", "b.zt"}, "", false, true},
+	}
+	for _, tt := range tests {
+		file, keepGoing, err := parseSource(tt.args)
+		if (err != nil) != tt.fails || file != tt.file || keepGoing != tt.keepGoing {
+			t.Errorf("parseSource(%q) = %q, %v, %v", tt.args, file, keepGoing, err)
+		}
+	}
+}
+
+func TestSourceRecursive(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "self.zt")
+	if err := os.WriteFile(file, []byte("source self.zt\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// script sourced in the chain of the same script (or of the script it was sourced from)
+	for _, sources := range [][]string{{file}, {file, filepath.Join(dir, "other.zt")}} {
+		cw := &chainWidget{sources: sources}
+		err := cmdSource(cw, "self.zt", false)
+		if err == nil || !strings.Contains(err.Error(), "recursive source") {
+			t.Errorf("source in script %q: got %v, want recursive source error", sources, err)
+		}
+		if cw.conn != nil {
+			t.Errorf("source in script %q: script started", sources)
+		}
+	}
+}
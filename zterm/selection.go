@@ -0,0 +1,325 @@
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/muesli/termenv"
+)
+
+// selection is a visual selection of the text in a view (line-wise or block-wise)
+type selection struct {
+	view   string // view with selected text
+	back   string // view selected after selection ends
+	block  bool
+	ax, ay int // anchor (where selection started) in view lines
+	cx, cy int // cursor
+}
+
+const selectView = "selection" // view over the selected text
+
+var sel *selection
+
+// startSelection returns keybind function which starts visual selection in the view (console output for console prompt)
+func startSelection(block bool) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if v == nil {
+			return nil
+		}
+		target := v
+		if v.Name() == cmdPrompt {
+			var err error
+			if target, err = g.View(cmdView); err != nil {
+				return nil
+			}
+		}
+		lines := target.ViewBufferLines()
+		if len(lines) == 0 {
+			return nil
+		}
+		// cursor starts on the last visible line
+		ox, oy := target.Origin()
+		_, h := target.Size()
+		cy := oy + h - 1
+		if cy >= len(lines) {
+			cy = len(lines) - 1
+		}
+		target.Autoscroll = false
+		sel = &selection{view: target.Name(), back: v.Name(), block: block, ax: ox, ay: cy, cx: ox, cy: cy}
+		if err := layoutSelection(g); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(selectView)
+		return err
+	}
+}
+
+// layoutSelection displays selected text in reverse colors over the view
+func layoutSelection(g *gocui.Gui) error {
+	if sel == nil {
+		g.DeleteView(selectView) // if doesn't exist, don't care
+		return nil
+	}
+	tv, err := g.View(sel.view)
+	if err != nil || !tv.Visible {
+		return endSelection(g)
+	}
+	lines := tv.ViewBufferLines()
+	x0, y0, x1, _ := tv.Dimensions()
+	ox, oy := tv.Origin()
+	w, h := tv.Size()
+	top, bottom := sel.rows()
+	if top < oy {
+		top = oy
+	}
+	if bottom > oy+h-1 {
+		bottom = oy + h - 1
+	}
+	if bottom >= len(lines) {
+		bottom = len(lines) - 1
+	}
+	if top > bottom {
+		bottom = top
+	}
+
+	// frameless view over the selected lines
+	vy := y0 + top - oy
+	v, err := g.SetView(selectView, x0, vy, x1, vy+bottom-top+2, 0)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return fmt.Errorf("view %v: %v", selectView, err)
+	}
+	v.Frame = false
+	v.Clear()
+	left, right := sel.columns()
+	for y := top; y <= bottom; y++ {
+		line := []rune{}
+		if y < len(lines) {
+			line = []rune(lines[y])
+		}
+		for len(line) < ox+w {
+			line = append(line, ' ')
+		}
+		var sb strings.Builder
+		for x := ox; x < ox+w; x++ {
+			selected := !sel.block || (x >= left && x <= right)
+			if selected {
+				sb.WriteString("\x1b[7m")
+			}
+			sb.WriteRune(line[x])
+			if selected {
+				sb.WriteString("\x1b[0m")
+			}
+		}
+		fmt.Fprintln(v, sb.String())
+	}
+	g.SetViewOnTop(selectView)
+	return nil
+}
+
+// rows returns first and last selected line
+func (s *selection) rows() (int, int) {
+	if s.ay < s.cy {
+		return s.ay, s.cy
+	}
+	return s.cy, s.ay
+}
+
+// columns returns first and last selected column (block-wise selection)
+func (s *selection) columns() (int, int) {
+	if s.ax < s.cx {
+		return s.ax, s.cx
+	}
+	return s.cx, s.ax
+}
+
+// text returns selected text
+func (s *selection) text(lines []string) string {
+	top, bottom := s.rows()
+	left, right := s.columns()
+	selected := []string{}
+	for y := top; y <= bottom && y < len(lines); y++ {
+		line := lines[y]
+		if s.block {
+			runes := []rune(line)
+			line = ""
+			if left < len(runes) {
+				end := right + 1
+				if end > len(runes) {
+					end = len(runes)
+				}
+				line = string(runes[left:end])
+			}
+		}
+		selected = append(selected, strings.TrimRight(line, " "))
+	}
+	return strings.Join(selected, "\n")
+}
+
+// moveSelection returns keybind function which moves cursor of the selection (view is scrolled to keep it visible)
+func moveSelection(dx, dy int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if sel == nil {
+			return nil
+		}
+		tv, err := g.View(sel.view)
+		if err != nil {
+			return endSelection(g)
+		}
+		lh := len(tv.ViewBufferLines())
+		sel.cx += dx
+		sel.cy += dy
+		if sel.cy >= lh {
+			sel.cy = lh - 1
+		}
+		if sel.cy < 0 {
+			sel.cy = 0
+		}
+		if sel.cx < 0 {
+			sel.cx = 0
+		}
+		// scroll to the cursor
+		ox, oy := tv.Origin()
+		w, h := tv.Size()
+		if sel.cy < oy {
+			oy = sel.cy
+		} else if sel.cy >= oy+h {
+			oy = sel.cy - h + 1
+		}
+		if !tv.Wrap {
+			if sel.cx < ox {
+				ox = sel.cx
+			} else if sel.cx >= ox+w {
+				ox = sel.cx - w + 1
+			}
+		}
+		tv.SetOrigin(ox, oy)
+		return layoutSelection(g)
+	}
+}
+
+// endSelection removes selection and selects the view where it started
+func endSelection(g *gocui.Gui) error {
+	if sel == nil {
+		return nil
+	}
+	back := sel.back
+	sel = nil
+	g.DeleteView(selectView)
+	if _, err := g.View(back); err == nil {
+		g.SetCurrentView(back)
+	} else {
+		setDefaultView(g)
+	}
+	return nil
+}
+
+// copySelection returns keybind function which copies selected text into clipboard and ends the selection
+// (with local clipboard command instead of OSC 52 when local is true)
+func copySelection(local bool) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if sel == nil {
+			return nil
+		}
+		tv, err := g.View(sel.view)
+		if err != nil {
+			return endSelection(g)
+		}
+		text := sel.text(tv.ViewBufferLines())
+		x0, _, x1, y1 := tv.Dimensions()
+		endSelection(g)
+
+		how, err := copyToClipboard(text, local)
+		msg := fmt.Sprintf("%v lines copied to clipboard (%v)", strings.Count(text, "\n")+1, how)
+		color := cPopup
+		if err != nil {
+			msg, color = fmt.Sprintf("copy to clipboard failed: %v", err), cError
+		}
+		addSimplePopupWidget("copy-popup", color, x0+1, y1-4, x1-x0-2, 3, msg)
+		return nil
+	}
+}
+
+// copyToClipboard copies text by clipboard command from config (like `xclip -selection clipboard`).
+// When the command is not configured, OSC 52 escape sequence is used (supported by most terminals, works over SSH too),
+// unless local is true or output is not a terminal which supports it.
+// Returns how the text was copied.
+func copyToClipboard(text string, local bool) (string, error) {
+	if config.Clipboard == "" {
+		switch {
+		case local:
+			return "", errors.New("clipboard command is not configured")
+		case !osc52Available():
+			return "", errors.New("OSC 52 is not available and clipboard command is not configured")
+		}
+		termenv.Copy(text)
+		return "OSC 52", nil
+	}
+	c := exec.Command("sh", "-c", config.Clipboard)
+	c.Stdin = strings.NewReader(text)
+	if out, err := c.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", fmt.Errorf("%s: %v", config.Clipboard, err)
+	}
+	return config.Clipboard, nil
+}
+
+// osc52Available checks if the output is a terminal which supports OSC 52 (linux console and dumb terminals don't)
+func osc52Available() bool {
+	fi, err := os.Stdout.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	term := os.Getenv("TERM")
+	return term != "dumb" && term != "linux"
+}
+
+// Keybinds for selection (start in views and console, move, copy and cancel)
+func keybindsSelection(g *gocui.Gui) {
+	// start in console (output of console)
+	if err := setKeybinding(g, cmdPrompt, gocui.KeyCtrlV, gocui.ModNone, "start selection in console output", startSelection(false)); err != nil {
+		log.Panicln(err)
+	}
+
+	lineWise := func(g *gocui.Gui, v *gocui.View) error { sel.block = false; return layoutSelection(g) }
+	cancel := func(g *gocui.Gui, v *gocui.View) error { return endSelection(g) }
+	keys := []struct {
+		key  interface{}
+		help string
+		fn   func(g *gocui.Gui, v *gocui.View) error
+	}{
+		{gocui.KeyArrowUp, "move selection", moveSelection(0, -1)},
+		{gocui.KeyArrowDown, "move selection", moveSelection(0, 1)},
+		{gocui.KeyArrowLeft, "move selection", moveSelection(-1, 0)},
+		{gocui.KeyArrowRight, "move selection", moveSelection(1, 0)},
+		{gocui.KeyPgup, "move selection by page", moveSelection(0, -pageScroll)},
+		{gocui.KeyPgdn, "move selection by page", moveSelection(0, pageScroll)},
+		{gocui.KeyHome, "move selection to the top", moveSelection(0, -1<<30)},
+		{gocui.KeyEnd, "move selection to the bottom", moveSelection(0, 1<<30)},
+		// switch line-wise and block-wise
+		{'V', "line-wise selection", lineWise},
+		{gocui.KeyCtrlV, "switch line-wise and block-wise selection", func(g *gocui.Gui, v *gocui.View) error {
+			sel.block = !sel.block
+			return layoutSelection(g)
+		}},
+		// copy
+		{'y', "copy selection to clipboard", copySelection(false)},
+		{gocui.KeyEnter, "copy selection to clipboard", copySelection(false)},
+		{'Y', "copy selection with local clipboard command", copySelection(true)},
+		// cancel
+		{'q', "cancel selection", cancel},
+		{gocui.KeyEsc, "cancel selection", cancel},
+		{gocui.KeyTab, "cancel selection", cancel},
+	}
+	for _, k := range keys {
+		if err := setKeybinding(g, selectView, k.key, gocui.ModNone, k.help, k.fn); err != nil {
+			log.Panicln(err)
+		}
+	}
+}
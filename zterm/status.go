@@ -0,0 +1,192 @@
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/mattn/go-runewidth"
+)
+
+// errJobTimeout is error of the job killed by timeout
+var errJobTimeout = errors.New("timeout")
+
+// exitStatusError is error of the command with its exit code (like exit status of remote command)
+type exitStatusError struct {
+	code int
+	msg  string
+}
+
+func (e *exitStatusError) Error() string {
+	return e.msg
+}
+
+// exitCode returns exit code of the command by its error (-1 when it's unknown)
+func exitCode(err error) int {
+	var se *exitStatusError
+	var ee *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &se):
+		return se.code
+	case errors.As(err, &ee):
+		return ee.ExitCode()
+	}
+	return -1
+}
+
+// jobStatus is run metadata of the view job
+type jobStatus struct {
+	runs        int
+	exitCode    int   // exit code of the last run (-1 when unknown)
+	err         error // error of the last run
+	duration    time.Duration
+	lastRun     time.Time // end of the last run
+	lastSuccess time.Time
+	failures    int // consecutive failures
+}
+
+// record saves result of the job run
+func (s *jobStatus) record(start, end time.Time, err error) {
+	s.runs++
+	s.err = err
+	s.exitCode = exitCode(err)
+	s.duration = end.Sub(start)
+	s.lastRun = end
+	if err == nil {
+		s.lastSuccess = end
+		s.failures = 0
+	} else {
+		s.failures++
+	}
+}
+
+// badge returns short status of the last run for the view title (like `✔ 0.4s 12:01:05` or `✖ rc=8`)
+func (s *jobStatus) badge() string {
+	if s.runs == 0 {
+		return ""
+	}
+	if s.err == nil {
+		return fmt.Sprintf("✔ %s %s", formatDuration(s.duration), s.lastRun.Format("15:04:05"))
+	}
+	badge := "✖ " + s.result()
+	if s.failures > 1 {
+		badge += fmt.Sprintf(" (%dx)", s.failures)
+	}
+	return badge
+}
+
+// result returns result of the last run (ok, rc=N, timeout or error)
+func (s *jobStatus) result() string {
+	switch {
+	case s.err == nil:
+		return "ok"
+	case errors.Is(s.err, errJobTimeout):
+		return "timeout"
+	case s.exitCode >= 0:
+		return fmt.Sprintf("rc=%d", s.exitCode)
+	}
+	return "error"
+}
+
+// formatDuration returns short duration (like 0.4s, 12.5s or 2m5s)
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return d.Round(time.Second).String()
+}
+
+// recordRun saves result of the job run (in GUI thread, so it's displayed in the title)
+func (ws *WidgetStack) recordRun(start time.Time, err error) {
+	end := time.Now()
+	gui.Update(func(g *gocui.Gui) error {
+		ws.status.record(start, end, err)
+		return nil
+	})
+}
+
+// jobStatusTable returns status of the jobs of all views (or only of specified ones)
+func jobStatusTable(names []string) (string, error) {
+	stacks := []*WidgetStack{}
+	if len(names) > 0 {
+		for _, name := range names {
+			ws := getWidgetStack(name)
+			if ws == nil {
+				return "", fmt.Errorf("status: view '%s' doesn't exist", name)
+			}
+			stacks = append(stacks, ws)
+		}
+	} else {
+		for _, w := range widgets {
+			if ws, ok := w.(*WidgetStack); ok {
+				stacks = append(stacks, ws)
+			}
+		}
+		sort.Slice(stacks, func(i, j int) bool {
+			return stacks[i].name < stacks[j].name
+		})
+	}
+	if len(stacks) == 0 {
+		return "", errors.New("status: no views")
+	}
+
+	rows := [][]string{{"VIEW", "STATE", "RESULT", "DURATION", "LAST RUN", "LAST SUCCESS", "FAILURES", "JOB"}}
+	for _, ws := range stacks {
+		s := ws.status
+		state, result, duration, lastRun, lastSuccess := "idle", "-", "-", "-", "-"
+		switch {
+		case ws.Fun == nil:
+			state = "no job"
+		case ws.paused:
+			state = "paused"
+		case ws.schedule != nil && !ws.schedule.matches(time.Now()):
+			state = "off schedule"
+		case ws.conn != nil && !ws.conn.ended():
+			state = "running"
+		}
+		if s.runs > 0 {
+			result = s.result()
+			duration = formatDuration(s.duration)
+			lastRun = s.lastRun.Format("15:04:05")
+		}
+		if !s.lastSuccess.IsZero() {
+			lastSuccess = s.lastSuccess.Format("15:04:05")
+		}
+		rows = append(rows, []string{ws.name, state, result, duration, lastRun, lastSuccess,
+			fmt.Sprint(s.failures), ws.GetFunString()})
+	}
+	return formatColumns(rows), nil
+}
+
+// formatColumns aligns columns of the rows (last column isn't padded)
+func formatColumns(rows [][]string) string {
+	widths := []int{}
+	for _, row := range rows {
+		for i, col := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := runewidth.StringWidth(col); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	lines := []string{}
+	for _, row := range rows {
+		cols := []string{}
+		for i, col := range row {
+			if i < len(row)-1 {
+				col = runewidth.FillRight(col, widths[i])
+			}
+			cols = append(cols, col)
+		}
+		lines = append(lines, " "+strings.Join(cols, "  "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,173 @@
+package zterm
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// cmdLine is a console command split into arguments (with positions of arguments in the raw line)
+type cmdLine struct {
+	raw  string
+	args []string
+	offs []int
+}
+
+// tokenize splits command line into arguments like shell does.
+// Arguments are separated by whitespace, single quotes keep the text as is, double quotes
+// keep whitespace but expand variables, backslash escapes next character and `$VAR` or `${VAR}`
+// is replaced by environment variable.
+func tokenize(line string) (*cmdLine, error) {
+	return parseLine(line, false)
+}
+
+// tokenizePartial splits command line which is being typed (for completion).
+// Unterminated quote is not an error and whitespace at the end starts new empty argument.
+func tokenizePartial(line string) *cmdLine {
+	cl, _ := parseLine(line, true)
+	return cl
+}
+
+// rest returns raw text of the line from n-th argument (quotes are kept, so it can be passed to shell)
+func (cl *cmdLine) rest(n int) string {
+	if n >= len(cl.offs) {
+		return ""
+	}
+	return strings.TrimSpace(cl.raw[cl.offs[n]:])
+}
+
+// shellRest returns rest of the line from n-th argument for shell (like rest).
+// When it's only one quoted argument, quotes are removed, so the command can contain `;` or `&&`.
+func (cl *cmdLine) shellRest(n int) string {
+	if len(cl.args) == n+1 && strings.ContainsAny(cl.raw[cl.offs[n]:cl.offs[n]+1], `'"`) {
+		return cl.args[n]
+	}
+	return cl.rest(n)
+}
+
+func parseLine(line string, partial bool) (*cmdLine, error) {
+	cl := &cmdLine{raw: line}
+	runes := []rune(line)
+	var sb strings.Builder
+	start := -1     // byte offset of current argument (-1 when outside of argument)
+	quoted := false // argument contains quotes (so empty argument is kept)
+	var quote rune  // current quote character
+	pos := 0        // byte offset of the current rune
+	finish := func() {
+		if start >= 0 && (sb.Len() > 0 || quoted) {
+			cl.args = append(cl.args, sb.String())
+			cl.offs = append(cl.offs, start)
+		}
+		sb.Reset()
+		start, quoted = -1, false
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		size := len(string(r))
+		switch {
+		case quote == 0 && unicode.IsSpace(r):
+			finish()
+		case quote == 0 && (r == '\'' || r == '"'):
+			if start < 0 {
+				start = pos
+			}
+			quote, quoted = r, true
+		case quote == r:
+			quote = 0
+		case r == '\\' && quote != '\'':
+			if start < 0 {
+				start = pos
+			}
+			if i+1 < len(runes) {
+				next := runes[i+1]
+				// in double quotes backslash escapes only special characters
+				if quote == '"' && !strings.ContainsRune("\"\\$`", next) {
+					sb.WriteRune(r)
+				} else {
+					sb.WriteRune(next)
+					i++
+					size += len(string(next))
+				}
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '$' && quote != '\'':
+			if start < 0 {
+				start = pos
+			}
+			name, n := varName(runes[i+1:])
+			if name == "" {
+				sb.WriteRune(r)
+				break
+			}
+			sb.WriteString(os.Getenv(name))
+			for _, vr := range runes[i+1 : i+1+n] {
+				size += len(string(vr))
+			}
+			i += n
+		default:
+			if start < 0 {
+				start = pos
+			}
+			sb.WriteRune(r)
+		}
+		pos += size
+	}
+	if quote != 0 && !partial {
+		return cl, errors.New("unterminated quote")
+	}
+	finish()
+	if partial && (len(cl.args) == 0 || (quote == 0 && unicode.IsSpace(runes[len(runes)-1]))) {
+		cl.args = append(cl.args, "")
+		cl.offs = append(cl.offs, len(line))
+	}
+	return cl, nil
+}
+
+// varName returns name of variable at the beginning of text (`VAR` or `{VAR}`) and number of runes it takes
+func varName(text []rune) (string, int) {
+	if len(text) > 0 && text[0] == '{' {
+		for i, r := range text {
+			if r == '}' {
+				return string(text[1:i]), i + 1
+			}
+		}
+		return "", 0
+	}
+	n := 0
+	for n < len(text) && (text[n] == '_' || unicode.IsLetter(text[n]) || (n > 0 && unicode.IsDigit(text[n]))) {
+		n++
+	}
+	return string(text[:n]), n
+}
+
+// quoteArg quotes argument for command line when it contains special characters
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t'\"\\$") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// joinLines converts multi-line command into one line.
+// Backslash at the end of line continues the line, other lines are separated by `;` (unless the line ends with `;` or `&&`).
+func joinLines(text string) string {
+	text = strings.ReplaceAll(text, "\\\n", "")
+	joined := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if joined != "" && !strings.HasSuffix(joined, ";") && !strings.HasSuffix(joined, "&&") {
+			joined += ";"
+		}
+		if joined != "" {
+			joined += " "
+		}
+		joined += line
+	}
+	return joined
+}
@@ -0,0 +1,111 @@
+package zterm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	t.Setenv("ZT_USER", "ibmuser")
+	t.Setenv("ZT_EMPTY", "")
+	tests := []struct {
+		line string
+		args []string
+		offs []int
+	}{
+		{"", nil, nil},
+		{"  addview   joblog  ", []string{"addview", "joblog"}, []int{2, 12}},
+		{`view joblog hi-line "ABEND S0C4"`, []string{"view", "joblog", "hi-line", "ABEND S0C4"}, []int{0, 5, 12, 20}},
+		{`echo 'a "b" $ZT_USER'`, []string{"echo", `a "b" $ZT_USER`}, []int{0, 5}},
+		{`echo "$ZT_USER is ${ZT_USER}"`, []string{"echo", "ibmuser is ibmuser"}, []int{0, 5}},
+		{`echo $ZT_USER/jcl x$ZT_EMPTY`, []string{"echo", "ibmuser/jcl", "x"}, []int{0, 5, 18}},
+		{`echo a\ b \'c\'`, []string{"echo", "a b", "'c'"}, []int{0, 5, 10}},
+		{`echo "a\"b" "c\d" "\$x"`, []string{"echo", `a"b`, `c\d`, "$x"}, []int{0, 5, 12, 18}},
+		{`echo '' ""`, []string{"echo", "", ""}, []int{0, 5, 8}},
+		{`echo $ZT_EMPTY`, []string{"echo"}, []int{0}},
+		{`echo $ $1 ${}`, []string{"echo", "$", "$1", "${}"}, []int{0, 5, 7, 10}},
+		{`echo a"b c"d`, []string{"echo", "ab cd"}, []int{0, 5}},
+		{`echo trailing\`, []string{"echo", `trailing\`}, []int{0, 5}},
+		{"view ž hi-line 日本", []string{"view", "ž", "hi-line", "日本"}, []int{0, 5, 8, 16}},
+	}
+	for _, tt := range tests {
+		cl, err := tokenize(tt.line)
+		if err != nil {
+			t.Errorf("tokenize(%q): %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(cl.args, tt.args) || !reflect.DeepEqual(cl.offs, tt.offs) {
+			t.Errorf("tokenize(%q) = %q %v, want %q %v", tt.line, cl.args, cl.offs, tt.args, tt.offs)
+		}
+	}
+}
+
+func TestTokenizeUnterminatedQuote(t *testing.T) {
+	for _, line := range []string{`echo "abc`, `echo 'abc`, `echo "a'b`} {
+		if _, err := tokenize(line); err == nil {
+			t.Errorf("tokenize(%q): expected error", line)
+		}
+	}
+}
+
+func TestTokenizePartial(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+		offs []int
+	}{
+		{"", []string{""}, []int{0}},
+		{"vi", []string{"vi"}, []int{0}},
+		{"view ", []string{"view", ""}, []int{0, 5}},
+		{"view job", []string{"view", "job"}, []int{0, 5}},
+		{`rvim "/u/my dir/`, []string{"rvim", "/u/my dir/"}, []int{0, 5}},
+		{`rvim "/u/my dir `, []string{"rvim", "/u/my dir "}, []int{0, 5}},
+	}
+	for _, tt := range tests {
+		cl := tokenizePartial(tt.line)
+		if !reflect.DeepEqual(cl.args, tt.args) || !reflect.DeepEqual(cl.offs, tt.offs) {
+			t.Errorf("tokenizePartial(%q) = %q %v, want %q %v", tt.line, cl.args, cl.offs, tt.args, tt.offs)
+		}
+	}
+}
+
+func TestCmdLineRest(t *testing.T) {
+	t.Setenv("ZT_USER", "ibmuser")
+	tests := []struct {
+		line      string
+		n         int
+		rest      string
+		shellRest string
+	}{
+		{"attach joblog remote jls", 2, "remote jls", "remote jls"},
+		{`attach logs  tail -n 20 "/var/log/my log"  `, 2, `tail -n 20 "/var/log/my log"`, `tail -n 20 "/var/log/my log"`},
+		{"remote 'cd /tmp && ls'", 1, "'cd /tmp && ls'", "cd /tmp && ls"},
+		{`remote "echo $ZT_USER"`, 1, `"echo $ZT_USER"`, "echo ibmuser"},
+		{`remote 'a' 'b'`, 1, `'a' 'b'`, `'a' 'b'`},
+		{`remote x'a b'`, 1, `x'a b'`, `x'a b'`},
+		{"remote", 1, "", ""},
+		{"remote ls", 5, "", ""},
+	}
+	for _, tt := range tests {
+		cl, err := tokenize(tt.line)
+		if err != nil {
+			t.Errorf("tokenize(%q): %v", tt.line, err)
+			continue
+		}
+		if got := cl.rest(tt.n); got != tt.rest {
+			t.Errorf("rest(%d) of %q = %q, want %q", tt.n, tt.line, got, tt.rest)
+		}
+		if got := cl.shellRest(tt.n); got != tt.shellRest {
+			t.Errorf("shellRest(%d) of %q = %q, want %q", tt.n, tt.line, got, tt.shellRest)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	for _, arg := range []string{"abc", "", "a b", "it's", `a"b`, `a\b`, "$HOME", "日本 語"} {
+		cl, err := tokenize("echo " + quoteArg(arg))
+		if err != nil || len(cl.args) != 2 || cl.args[1] != arg {
+			t.Errorf("quoteArg(%q) = %q is not parsed back", arg, quoteArg(arg))
+		}
+	}
+}
@@ -1,219 +1,360 @@
-package zterm
-
-import (
-	"strings"
-	"time"
-
-	"github.com/awesome-gocui/gocui"
-)
-
-// RecvConn struct contains channels for output and error string and error
-type RecvConn struct {
-	outchan chan string
-	err     chan error
-	signal  chan struct{}
-	sigEnd  chan bool
-}
-
-// NewRecvConn create new connection to receive output from command/function
-func NewRecvConn() *RecvConn {
-	return &RecvConn{
-		outchan: make(chan string, 10),
-		err:     make(chan error, 1),
-		signal:  make(chan struct{}),
-		sigEnd:  make(chan bool, 1),
-	}
-}
-
-// Stop command/function origin by sending ending signal.
-//
-// *it can be called multiple times, it doesn't block*
-func (conn *RecvConn) Stop() {
-	select {
-	case <-conn.signal:
-	default:
-		close(conn.signal)
-	}
-}
-
-// IsEnd return ending signal. Check if it's closed to confirm if it is end.
-func (conn *RecvConn) IsEnd() <-chan struct{} {
-	return conn.signal
-}
-
-// WaitEnd blocks the processing until the connected command/function ends.
-func (conn *RecvConn) WaitEnd() {
-	<-conn.signal
-	return
-}
-
-func (conn *RecvConn) send() {
-}
-
-func changeView(g *gocui.Gui, v *gocui.View) error {
-	curr, next := "", ""
-	if v != nil {
-		curr = v.Name()
-	}
-
-	// find next view in WidgetStack (from current)
-	next = nextWidgetStack(curr)
-	if next != "" {
-		g.SetCurrentView(next)
-	} else {
-		setDefaultView(g)
-	}
-	return nil
-}
-
-func nextWidgetStack(name string) (next string) {
-	wslist := getSortedWidgetStack()
-	nextidx := 0
-	for i, ws := range wslist {
-		if ws.GetName() == name {
-			nextidx = i + 1
-			break
-		}
-	}
-	if nextidx >= len(wslist) && len(wslist) > 0 {
-		next = wslist[0].GetName()
-	} else if len(wslist) > 0 {
-		next = wslist[nextidx].GetName()
-	}
-	return
-}
-
-// SetDefaultView to first one in WidgetStack list (if none, do not set).
-func setDefaultView(g *gocui.Gui) {
-	if wslist := getSortedWidgetStack(); len(wslist) > 0 {
-		g.SetCurrentView(wslist[0].GetName())
-	}
-}
-
-// Put text into the View. This will delete the previous content
-func textToView(w Widgeter, outstr string) {
-	if w != nil && !w.IsHidden() {
-		gui.UpdateAsync(func(g *gocui.Gui) error {
-			w.Clear()
-			if len(outstr) > 0 {
-				w.Print(outstr)
-			}
-			return nil
-		})
-	}
-}
-
-// Append text to the View. This will preserve previously added content
-func appendTextToView(w Widgeter, outstr string) {
-	if w != nil && !w.IsHidden() {
-		gui.UpdateAsync(func(g *gocui.Gui) error {
-			w.Print(outstr)
-			return nil
-		})
-	}
-}
-
-// Append highlighter error message to the View. This will preserve previously added content
-func appendErrorMsgToView(w Widgeter, err error) {
-	if w != nil {
-		gui.UpdateAsync(func(g *gocui.Gui) error {
-			w.Error(err)
-			return nil
-		})
-	}
-}
-
-// Connect widget view to receive content from channels
-func connectWidgetOuput(w Widgeter, conn *RecvConn) {
-	if conn == nil {
-		return
-	}
-
-	// connect widget for communication
-	w.Connect(conn)
-
-	go func() {
-		output := ""
-		first := true
-	renderloop:
-		for {
-			select {
-			case <-suspendChan:
-				// wait for resume
-				<-resumeChan
-			case out, ok := <-conn.outchan:
-				output += out + "\n"
-				if !ok {
-					if len(strings.TrimSpace(output)) > 0 {
-						if first {
-							textToView(w, output)
-							first = false
-						} else {
-							appendTextToView(w, output)
-						}
-					}
-					// don't need to clean output, just break out
-					break renderloop
-				}
-			case <-time.After(16 * time.Millisecond):
-				// display in FPS ~60hz
-				if len(strings.TrimSpace(output)) > 0 {
-					// display only when there is some text
-					if first {
-						textToView(w, output)
-						first = false
-					} else {
-						appendTextToView(w, output)
-					}
-					output = ""
-				}
-			}
-
-		}
-		// add to renderloop???
-		for err := range conn.err {
-			appendErrorMsgToView(w, err)
-		}
-		conn.Stop()
-	}()
-}
-
-func quit(g *gocui.Gui, v *gocui.View) error {
-	return gocui.ErrQuit
-}
-
-func scrollView(v *gocui.View, dy int) error {
-	if v != nil {
-		v.Autoscroll = false
-		ox, oy := v.Origin()
-		lh := v.LinesHeight()
-		v.Subtitle = ""
-		// verify to not scroll out
-		if oy+dy < 0 {
-			dy = -oy
-			v.Subtitle = "[ TOP ]"
-		} else if oy+dy >= (lh - 5) {
-			dy = lh - oy - 5 // scroll at the bottom to display last 5 lines
-			v.Subtitle = "[ BOTTOM ]"
-		}
-		if err := v.SetOrigin(ox, oy+dy); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func sideScrollView(v *gocui.View, dx int) error {
-	if v != nil {
-		v.Wrap = false
-		ox, oy := v.Origin()
-		// verify to not scroll out
-		if ox+dx < 0 {
-			dx = -ox
-		}
-		if err := v.SetOrigin(ox+dx, oy); err != nil {
-			return err
-		}
-	}
-	return nil
-}
+package zterm
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+const promptDelay = 200 * time.Millisecond // unfinished line of interactive command is displayed after this delay
+
+// RecvConn struct contains channels for output and error string and error
+type RecvConn struct {
+	outchan chan string
+	err     chan error
+	signal  chan struct{}
+	sigEnd  chan bool
+	failed  bool        // command ended with error (valid after the end)
+	failure error       // last error of the command (valid after the end)
+	input   chan string // lines for stdin of the command (nil when command doesn't get input)
+	eof     bool        // stdin of the command was closed
+}
+
+// NewRecvConn create new connection to receive output from command/function
+func NewRecvConn() *RecvConn {
+	return &RecvConn{
+		outchan: make(chan string, 10),
+		err:     make(chan error, 1),
+		signal:  make(chan struct{}),
+		sigEnd:  make(chan bool, 1),
+	}
+}
+
+// Stop command/function origin by sending ending signal.
+//
+// *it can be called multiple times, it doesn't block*
+func (conn *RecvConn) Stop() {
+	select {
+	case <-conn.signal:
+	default:
+		close(conn.signal)
+	}
+}
+
+// IsEnd return ending signal. Check if it's closed to confirm if it is end.
+func (conn *RecvConn) IsEnd() <-chan struct{} {
+	return conn.signal
+}
+
+// ended checks if the command/function already ended
+func (conn *RecvConn) ended() bool {
+	select {
+	case <-conn.signal:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitEnd blocks the processing until the connected command/function ends.
+func (conn *RecvConn) WaitEnd() {
+	<-conn.signal
+	return
+}
+
+func (conn *RecvConn) send() {
+}
+
+// setInput connects stdin of the command, lines are written in background (so UI doesn't wait for the command to read them)
+func (conn *RecvConn) setInput(stdin io.WriteCloser) {
+	conn.input = make(chan string, 100)
+	go func() {
+		defer stdin.Close()
+		for {
+			select {
+			case <-conn.signal:
+				return
+			case line, ok := <-conn.input:
+				if !ok {
+					return
+				}
+				if _, err := io.WriteString(stdin, line); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// readLines reads output of interactive command by lines and sends them to the connection (returns false when it's stopped).
+// Unfinished line is sent too, when the command doesn't write anything for a while (it's probably prompt waiting for input).
+func readLines(r io.Reader, conn *RecvConn) bool {
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		buf := make([]byte, 4096)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				select {
+				case <-conn.signal:
+					return
+				case chunks <- append([]byte{}, buf[:n]...):
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(line string) bool {
+		select {
+		case <-conn.signal:
+			return false
+		case conn.outchan <- strings.TrimSuffix(line, "\r"):
+			return true
+		}
+	}
+	partial := ""
+	for {
+		var idle <-chan time.Time
+		if partial != "" {
+			idle = time.After(promptDelay)
+		}
+		select {
+		case <-conn.signal:
+			return false
+		case data, ok := <-chunks:
+			if !ok {
+				return partial == "" || send(partial)
+			}
+			lines := strings.Split(partial+string(data), "\n")
+			partial = lines[len(lines)-1]
+			for _, line := range lines[:len(lines)-1] {
+				if !send(line) {
+					return false
+				}
+			}
+		case <-idle:
+			if !send(partial) {
+				return false
+			}
+			partial = ""
+		}
+	}
+}
+
+// WaitsInput checks if the command is running and its stdin is open
+func (conn *RecvConn) WaitsInput() bool {
+	select {
+	case <-conn.signal:
+		return false
+	default:
+		return conn.input != nil && !conn.eof
+	}
+}
+
+// Input sends line to stdin of the command (returns false when the command doesn't get input)
+func (conn *RecvConn) Input(line string) bool {
+	if !conn.WaitsInput() {
+		return false
+	}
+	select {
+	case conn.input <- line + "\n":
+		return true
+	default:
+		return false
+	}
+}
+
+// CloseInput closes stdin of the command (command gets EOF)
+func (conn *RecvConn) CloseInput() {
+	if conn.input != nil && !conn.eof {
+		conn.eof = true
+		close(conn.input)
+	}
+}
+
+func changeView(g *gocui.Gui, v *gocui.View) error {
+	curr, next := "", ""
+	if v != nil {
+		curr = v.Name()
+	}
+
+	// find next view in WidgetStack (from current)
+	next = nextWidgetStack(curr)
+	if next != "" {
+		g.SetCurrentView(next)
+	} else {
+		setDefaultView(g)
+	}
+	// zoom follows selected view
+	if cv := g.CurrentView(); zoomedView != "" && cv != nil {
+		zoomedView = cv.Name()
+	}
+	return nil
+}
+
+func nextWidgetStack(name string) (next string) {
+	wslist := getVisibleWidgetStack()
+	nextidx := 0
+	for i, ws := range wslist {
+		if ws.GetName() == name {
+			nextidx = i + 1
+			break
+		}
+	}
+	if nextidx >= len(wslist) && len(wslist) > 0 {
+		next = wslist[0].GetName()
+	} else if len(wslist) > 0 {
+		next = wslist[nextidx].GetName()
+	}
+	return
+}
+
+// SetDefaultView to first one in WidgetStack list (if none, do not set).
+func setDefaultView(g *gocui.Gui) {
+	if wslist := getVisibleWidgetStack(); len(wslist) > 0 {
+		g.SetCurrentView(wslist[0].GetName())
+	}
+}
+
+// Put text into the View. This will delete the previous content.
+// Hidden widgets get the text too (hidden view keeps its output and alert rules match it), only drawing is skipped.
+func textToView(w Widgeter, outstr string) {
+	if w != nil {
+		gui.UpdateAsync(func(g *gocui.Gui) error {
+			w.Clear()
+			if len(outstr) > 0 {
+				w.Print(outstr)
+			}
+			return nil
+		})
+	}
+}
+
+// Append text to the View. This will preserve previously added content (also of hidden widgets)
+func appendTextToView(w Widgeter, outstr string) {
+	if w != nil {
+		gui.UpdateAsync(func(g *gocui.Gui) error {
+			w.Print(outstr)
+			return nil
+		})
+	}
+}
+
+// Append highlighter error message to the View. This will preserve previously added content
+func appendErrorMsgToView(w Widgeter, err error) {
+	if w != nil {
+		gui.UpdateAsync(func(g *gocui.Gui) error {
+			w.Error(err)
+			return nil
+		})
+	}
+}
+
+// Connect widget view to receive content from channels
+func connectWidgetOuput(w Widgeter, conn *RecvConn) {
+	if conn == nil {
+		return
+	}
+
+	// connect widget for communication
+	w.Connect(conn)
+
+	go func() {
+		output := ""
+		first := true
+	renderloop:
+		for {
+			select {
+			case <-suspendChan:
+				// wait for resume
+				<-resumeChan
+			case out, ok := <-conn.outchan:
+				output += out + "\n"
+				if !ok {
+					if len(strings.TrimSpace(output)) > 0 {
+						if first {
+							textToView(w, output)
+							first = false
+						} else {
+							appendTextToView(w, output)
+						}
+					}
+					// don't need to clean output, just break out
+					break renderloop
+				}
+			case <-time.After(16 * time.Millisecond):
+				// display in FPS ~60hz
+				if len(strings.TrimSpace(output)) > 0 {
+					// display only when there is some text
+					if first {
+						textToView(w, output)
+						first = false
+					} else {
+						appendTextToView(w, output)
+					}
+					output = ""
+				}
+			}
+
+		}
+		// add to renderloop???
+		for err := range conn.err {
+			conn.failed = true
+			conn.failure = err
+			appendErrorMsgToView(w, err)
+		}
+		conn.Stop()
+		if conn.input != nil {
+			// redraw console (command doesn't wait for input anymore)
+			gui.Update(func(g *gocui.Gui) error {
+				return nil
+			})
+		}
+	}()
+}
+
+func quit(g *gocui.Gui, v *gocui.View) error {
+	return gocui.ErrQuit
+}
+
+func scrollView(v *gocui.View, dy int) error {
+	if v != nil {
+		v.Autoscroll = false
+		ox, oy := v.Origin()
+		lh := v.LinesHeight()
+		v.Subtitle = ""
+		// verify to not scroll out
+		if oy+dy < 0 {
+			dy = -oy
+			v.Subtitle = "[ TOP ]"
+		} else if oy+dy >= (lh - 5) {
+			dy = lh - oy - 5 // scroll at the bottom to display last 5 lines
+			v.Subtitle = "[ BOTTOM ]"
+		}
+		if err := v.SetOrigin(ox, oy+dy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sideScrollView(v *gocui.View, dx int) error {
+	if v != nil {
+		v.Wrap = false
+		ox, oy := v.Origin()
+		// verify to not scroll out
+		if ox+dx < 0 {
+			dx = -ox
+		}
+		if err := v.SetOrigin(ox+dx, oy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
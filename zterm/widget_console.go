@@ -1,360 +1,376 @@
-package zterm
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"log"
-	"strings"
-
-	"github.com/awesome-gocui/gocui"
-)
-
-// WidgetConsole structure for GUI
-type WidgetConsole struct {
-	Widget
-	lastView   string
-	cmdHistory []string
-	histIndex  int
-	cancel     context.CancelFunc
-}
-
-var (
-	cmdView       = "console"
-	cmdPrompt     = "console-prompt"
-	cmdPromptPS1  = "console-prompt-ps1"
-	consoleHeight = 3
-	promptPS1     = colorText(">> ", cConsoleStr)
-)
-
-// NewWidgetConsole creates a widget for GUI which doesn't contribute to the layout.
-// This type of widget is displayed on top over the layout.
-func NewWidgetConsole() *WidgetConsole {
-	promptPS1 = colorText(">> ", cConsoleStr) // reload color (from theme)
-	return &WidgetConsole{Widget: Widget{name: cmdView, Enabled: false}}
-}
-
-// Layout setup for console widget
-func (wc *WidgetConsole) Layout(g *gocui.Gui) error {
-	// do not display if disabled
-	if !wc.Enabled {
-		g.DeleteView(cmdView)      // if doesn't exist, don't care
-		g.DeleteView(cmdPrompt)    // ditto...
-		g.DeleteView(cmdPromptPS1) // ditto...
-		wc.gview = nil
-		// check if current view was pointing to this view before (just to be sure!)
-		if g.CurrentView() != nil && g.CurrentView().Name() == cmdPrompt {
-			if wc.lastView != "" {
-				g.SetCurrentView(wc.lastView)
-				wc.lastView = ""
-			} else {
-				setDefaultView(g)
-			}
-		}
-		return nil
-	}
-	// Enabled, display...
-	maxX, maxY := g.Size()
-	// compute correct position and width
-	maxHeight := consoleHeight
-	if wc.gview != nil {
-		if wc.gview.LinesHeight() > 0 {
-			maxHeight += wc.gview.LinesHeight() - 1
-		}
-		if maxHeight > int(float64(maxY)*0.6) {
-			maxHeight = int(float64(maxY) * 0.6)
-		}
-	}
-	yPos := maxY - 1 - maxHeight
-	width := maxX - 1
-
-	// set console "outer" window
-	v, err := g.SetView(cmdView, 0, yPos, width, yPos+maxHeight, 0)
-	if err != nil {
-		if !errors.Is(err, gocui.ErrUnknownView) {
-			return fmt.Errorf("view %v: %v", cmdView, err)
-		}
-		// wc.gview = v // set pointer to GUI View for wc.Clear() command
-		// wc.Clear()
-	}
-	wc.gview = v // set pointer to GUI View (only for view, not for input)
-	v.Wrap = true
-	// hardcoded colors for frame and title
-	v.FrameColor = cConsole
-	v.TitleColor = cConsole
-	v.FrameRunes = []rune{'═', '║', '╔', '╗', '╚', '╝'}
-
-	// set title
-	v.Title = fmt.Sprintf("< %v >", cmdView)
-	g.SetViewOnTop(cmdView)
-
-	// set consol prompt PS1
-	v, err = g.SetView(cmdPromptPS1, 0, yPos+maxHeight-2, 4, yPos+maxHeight, 0)
-	if err != nil {
-		if !errors.Is(err, gocui.ErrUnknownView) {
-			return fmt.Errorf("view %v: %v", cmdView, err)
-		}
-		fmt.Fprint(v, promptPS1)
-	}
-	v.Frame = false
-	g.SetViewOnTop(cmdPromptPS1)
-
-	// set console "input" line
-	v, err = g.SetView(cmdPrompt, 3, yPos+maxHeight-2, width, yPos+maxHeight, 0)
-	if err != nil {
-		if !errors.Is(err, gocui.ErrUnknownView) {
-			return fmt.Errorf("view %v: %v", cmdView, err)
-		}
-	}
-
-	// save last CurrentView
-	if cv := g.CurrentView(); cv != nil && cv.Name() != cmdPrompt {
-		wc.lastView = cv.Name()
-	}
-	// set editing
-	v.Editable = true
-	v.Autoscroll = false
-	v.Frame = false
-	g.SetViewOnTop(cmdPrompt)
-	g.SetCurrentView(cmdPrompt)
-	v.Editor = gocui.EditorFunc(consoleEditor)
-
-	return nil
-}
-
-// Clear override to not clear the console output (this is triggered everytime new command is issued in update)
-func (wc *WidgetConsole) Clear() {
-	// wc.gview.Clear()
-}
-
-// Error print error message to the console output line (second line below prompt)
-func (wc *WidgetConsole) Error(err error) {
-	wc.gview.Autoscroll = true
-	fmt.Fprintf(wc.gview, "%v %v\n\n", colorText("error:", cErrorStr), err.Error())
-}
-
-// Print message to the console output line
-func (wc *WidgetConsole) Print(msg string) {
-	wc.gview.Autoscroll = true
-	fmt.Fprint(wc.gview, msg)
-}
-
-// Println prints message to the console output line and add new line at the end
-func (wc *WidgetConsole) Println(msg string) {
-	wc.gview.Autoscroll = true
-	fmt.Fprintln(wc.gview, msg)
-}
-
-// Printf print formatted message to the console output line (second line below prompt)
-func (wc *WidgetConsole) Printf(format string, a ...interface{}) {
-	wc.gview.Autoscroll = true
-	fmt.Fprintf(wc.gview, format, a...) // should there be ... ???
-}
-
-// Keybinds for specific widget
-func (wc *WidgetConsole) Keybinds(g *gocui.Gui) {
-	// setup Tab for autocompletion (because it's global key, so to work in console, overwrite)
-	if err := g.SetKeybinding(cmdPrompt, gocui.KeyTab, gocui.ModNone, autoComplete); err != nil {
-		log.Panicln(err)
-	}
-	// cancel key
-	if err := g.SetKeybinding(cmdPrompt, gocui.KeyCtrlZ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		if v.Name() == cmdPrompt && wc.cancel != nil {
-			wc.cancel()
-		}
-		return nil
-	}); err != nil {
-		log.Panicln(err)
-	}
-}
-
-// ExecCmd execute command in the Console Widget
-func (wc *WidgetConsole) ExecCmd(cmd string) {
-	// add to history and update index
-	wc.cmdHistory = append(wc.cmdHistory, cmd)
-	wc.histIndex = len(wc.cmdHistory)
-	if err := commandExecute(wc, cmd); err != nil {
-		wc.Println(promptPS1 + cmd)
-		wc.Error(err)
-	} else {
-		wc.Println(promptPS1 + cmd)
-	}
-}
-
-// PrevHistory go back in history and return command from it
-func (wc *WidgetConsole) PrevHistory() string {
-	if len(wc.cmdHistory) > 0 {
-		wc.histIndex--
-		if wc.histIndex >= len(wc.cmdHistory) {
-			wc.histIndex = len(wc.cmdHistory)
-			return ""
-		} else if wc.histIndex < 0 {
-			wc.histIndex = 0
-			return wc.cmdHistory[0]
-		} else {
-			return wc.cmdHistory[wc.histIndex]
-		}
-	}
-	return ""
-}
-
-// NextHistory go forward in history and return command from it
-func (wc *WidgetConsole) NextHistory() string {
-	if len(wc.cmdHistory) > 0 {
-		wc.histIndex++
-		if wc.histIndex >= len(wc.cmdHistory) {
-			wc.histIndex = len(wc.cmdHistory)
-			return ""
-		} else if wc.histIndex < 0 {
-			wc.histIndex = 0
-			return wc.cmdHistory[0]
-		} else {
-			return wc.cmdHistory[wc.histIndex]
-		}
-	}
-	return ""
-}
-
-// ShowConsole is an update function which should be bound to a key
-func showConsole(g *gocui.Gui, v *gocui.View) error {
-	if wc := getConsoleWidget(); wc != nil {
-		if wc.IsHidden() {
-			wc.Enabled = true
-		} else {
-			wc.Disconnect()
-			wc.Enabled = false
-			wc.Layout(g)
-			// check if current view was pointing to this view before (just to be sure!)
-			if g.CurrentView() != nil && g.CurrentView().Name() == cmdPrompt {
-				if wc.lastView != "" {
-					g.SetCurrentView(wc.lastView)
-					wc.lastView = ""
-				} else {
-					setDefaultView(g)
-				}
-			}
-		}
-		return nil
-	}
-	// WTF?? what did I do? Where is my console??
-	return gocui.ErrUnknownView // this error doesn't comfort the new errors in gocui (but whatev)
-}
-
-// Console Editor (special setup for keys)
-func consoleEditor(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
-	var wc *WidgetConsole
-	if wc = getConsoleWidget(); wc == nil {
-		// if no console widget... wtf are we doing here??
-		return
-	}
-
-	switch {
-	case ch != 0 && mod == 0:
-		v.EditWrite(ch)
-	case key == gocui.KeySpace:
-		v.EditWrite(' ')
-	case key == gocui.KeyBackspace || key == gocui.KeyBackspace2:
-		v.EditDelete(true)
-	case key == gocui.KeyDelete:
-		v.EditDelete(false)
-	case key == gocui.KeyInsert:
-		v.Overwrite = !v.Overwrite
-	case key == gocui.KeyEnter:
-		// command exec
-		if line, err := v.Line(0); err == nil {
-			wc.ExecCmd(line)
-			v.Clear()
-			v.SetCursor(0, 0)
-		}
-	case key == gocui.KeyHome:
-		x, _ := v.Cursor()
-		v.MoveCursor(-x, 1)
-	case key == gocui.KeyEnd:
-		x, y := v.Cursor()
-		if line, err := v.Line(y); err == nil {
-			v.MoveCursor(len(line)-x, 0)
-		}
-	case key == gocui.KeyArrowDown:
-		// command history
-		if wc := getConsoleWidget(); wc != nil {
-			newcmd := wc.NextHistory()
-			v.Clear()
-			fmt.Fprint(v, newcmd)
-			v.SetCursor(len(newcmd), 0)
-		}
-	case key == gocui.KeyArrowUp:
-		// command history
-		if wc := getConsoleWidget(); wc != nil {
-			newcmd := wc.PrevHistory()
-			v.Clear()
-			fmt.Fprint(v, newcmd)
-			v.SetCursor(len(newcmd), 0)
-		}
-	case key == gocui.KeyArrowLeft:
-		v.MoveCursor(-1, 0)
-	case key == gocui.KeyArrowRight:
-		v.MoveCursor(1, 0)
-	// from awesome-gocui (new addition?)
-	case key == gocui.KeyCtrlU:
-		v.EditDeleteToStartOfLine()
-	case key == gocui.KeyCtrlA:
-		v.EditGotoToStartOfLine()
-	case key == gocui.KeyCtrlE:
-		v.EditGotoToEndOfLine()
-
-	// these are for console output view (not for the actual command line)
-	case key == gocui.KeyPgup:
-		if wc := getConsoleWidget(); wc != nil {
-			scrollView(wc.gview, -10)
-		}
-
-	case key == gocui.KeyPgdn:
-		if wc := getConsoleWidget(); wc != nil {
-			scrollView(wc.gview, 10)
-		}
-
-	default:
-		v.EditWrite(ch)
-	}
-
-}
-
-func autoComplete(g *gocui.Gui, v *gocui.View) error {
-	// autocompletion
-	if line, err := v.Line(0); err == nil {
-		cmdParts := strings.Split(line, " ")
-		clen := len(cmdParts)
-		var final []string
-		if clen == 1 {
-			autocomp := cmdParts[0]
-			for c := range cmdAuto {
-				if strings.HasPrefix(c, autocomp) {
-					final = append(final, c)
-				}
-			}
-		} else if clen == 2 {
-			autocomp := cmdParts[1]
-			for _, c := range cmdAuto[cmdParts[0]] {
-				if strings.HasPrefix(c, autocomp) {
-					final = append(final, c)
-				}
-			}
-		}
-		// finish command or process output for console message
-		if len(final) == 1 {
-			v.Clear()
-			var finalcmd string
-			if clen == 1 {
-				finalcmd = final[0] + " "
-			} else if clen == 2 {
-				finalcmd = cmdParts[0] + " " + final[0] + " "
-			}
-			fmt.Fprint(v, finalcmd)
-			v.SetCursor(len(finalcmd), 0)
-		} else if len(final) > 1 {
-			wc := getConsoleWidget()
-			wc.Println(strings.Join(final, " "))
-		}
-	}
-	return nil
-}
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// WidgetConsole structure for GUI
+type WidgetConsole struct {
+	Widget
+	lastView   string
+	cmdHistory []string
+	histIndex  int
+	editor     lineEditor // command line of the prompt
+	scrolled   bool       // output is scrolled above multi-line prompt
+	histFailed bool       // saving of history failed (error is reported only once)
+}
+
+var (
+	cmdView       = "console"
+	cmdPrompt     = "console-prompt"
+	cmdPromptPS1  = "console-prompt-ps1"
+	consoleHeight = 3
+	promptHeight  = 5 // max lines of the prompt (longer command is scrolled)
+	promptPS1     = colorText(">> ", cConsoleStr)
+)
+
+// consoleKeys describes keys handled by console editor (besides line editor keys)
+var consoleKeys = [][2]string{
+	{"Enter", "execute command or send line to running command (line ending with \\ continues on the next line)"},
+	{"Ctrl+D", "send end of input to running command"},
+	{"Up, Down", "move to previous or next line, or walk thru command history"},
+	{"Ctrl+R", "reverse search in command history"},
+	{"PgUp, PgDn", "scroll console output"},
+}
+
+// NewWidgetConsole creates a widget for GUI which doesn't contribute to the layout.
+// This type of widget is displayed on top over the layout.
+func NewWidgetConsole() *WidgetConsole {
+	promptPS1 = colorText(">> ", cConsoleStr) // reload color (from theme)
+	wc := &WidgetConsole{Widget: Widget{name: cmdView, Enabled: false}}
+	wc.loadHistory()
+	return wc
+}
+
+// Layout setup for console widget
+func (wc *WidgetConsole) Layout(g *gocui.Gui) error {
+	// do not display if disabled
+	if !wc.Enabled {
+		g.DeleteView(cmdView)      // if doesn't exist, don't care
+		g.DeleteView(cmdPrompt)    // ditto...
+		g.DeleteView(cmdPromptPS1) // ditto...
+		compMenu, histSearch = nil, nil
+		g.DeleteView(compView)   // ditto...
+		g.DeleteView(searchView) // ditto...
+		wc.gview = nil
+		// check if current view was pointing to this view before (just to be sure!)
+		if g.CurrentView() != nil && g.CurrentView().Name() == cmdPrompt {
+			if wc.lastView != "" {
+				g.SetCurrentView(wc.lastView)
+				wc.lastView = ""
+			} else {
+				setDefaultView(g)
+			}
+		}
+		return nil
+	}
+	// Enabled, display...
+	maxX, maxY := g.Size()
+	width := maxX - 1
+	// command line is wrapped by the prompt width
+	rows, cx, cy := wc.editor.render(width - 4)
+	promptRows := len(rows)
+	if promptRows > promptHeight {
+		promptRows = promptHeight
+	}
+	// compute correct position and width
+	maxHeight := consoleHeight + promptRows - 1
+	if wc.gview != nil {
+		if wc.gview.LinesHeight() > 0 {
+			maxHeight += wc.gview.LinesHeight() - 1
+		}
+		if maxHeight > int(float64(maxY)*0.6) {
+			maxHeight = int(float64(maxY) * 0.6)
+		}
+	}
+	yPos := maxY - 1 - maxHeight
+	promptY := yPos + maxHeight - 1 - promptRows
+
+	// set console "outer" window
+	v, err := g.SetView(cmdView, 0, yPos, width, yPos+maxHeight, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) {
+			return fmt.Errorf("view %v: %v", cmdView, err)
+		}
+		// wc.gview = v // set pointer to GUI View for wc.Clear() command
+		// wc.Clear()
+	}
+	wc.gview = v // set pointer to GUI View (only for view, not for input)
+	v.Wrap = true
+	// hardcoded colors for frame and title
+	v.FrameColor = cConsole
+	v.TitleColor = cConsole
+	v.FrameRunes = []rune{'═', '║', '╔', '╗', '╚', '╝'}
+
+	// set title
+	v.Title = fmt.Sprintf("< %v >", cmdView)
+	if wc.conn != nil && wc.conn.WaitsInput() {
+		v.Title = fmt.Sprintf("< %v: input for running command (Ctrl+D ends input, Ctrl+Z stops command) >", cmdView)
+	}
+	g.SetViewOnTop(cmdView)
+
+	// multi-line prompt covers more lines of the output, so the end of output is scrolled above it
+	_, h := v.Size()
+	if lh := v.ViewLinesHeight(); promptRows > 1 && lh > h && (v.Autoscroll || wc.scrolled) {
+		v.Autoscroll = false
+		wc.scrolled = true
+		v.SetOrigin(0, lh-h-2+promptRows)
+	} else if wc.scrolled {
+		v.Autoscroll = true
+		wc.scrolled = false
+	}
+
+	// set consol prompt PS1
+	v, err = g.SetView(cmdPromptPS1, 0, promptY, 4, promptY+2, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) {
+			return fmt.Errorf("view %v: %v", cmdView, err)
+		}
+		fmt.Fprint(v, promptPS1)
+	}
+	v.Frame = false
+	g.SetViewOnTop(cmdPromptPS1)
+
+	// set console "input" line
+	v, err = g.SetView(cmdPrompt, 3, promptY, width, yPos+maxHeight, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) {
+			return fmt.Errorf("view %v: %v", cmdView, err)
+		}
+	}
+
+	// save last CurrentView
+	if cv := g.CurrentView(); cv != nil && cv.Name() != cmdPrompt && cv.Name() != selectView {
+		wc.lastView = cv.Name()
+	}
+	// set editing
+	v.Editable = true
+	v.Autoscroll = false
+	v.Frame = false
+	g.SetViewOnTop(cmdPrompt)
+	// selection in console output keeps the focus
+	if cv := g.CurrentView(); cv == nil || cv.Name() != selectView {
+		g.SetCurrentView(cmdPrompt)
+	}
+	v.Editor = gocui.EditorFunc(consoleEditor)
+	// display command line (scrolled to the cursor)
+	v.Clear()
+	fmt.Fprint(v, strings.Join(rows, "\n"))
+	if cy >= promptRows {
+		v.SetOrigin(0, cy-promptRows+1)
+	}
+	v.SetCursorUnrestricted(cx, cy)
+
+	// completion menu and reverse search over the last line of console output
+	if err := layoutCompletion(g, 0, promptY, width); err != nil {
+		return err
+	}
+	return layoutSearch(g, 0, promptY, width)
+}
+
+// Clear override to not clear the console output (this is triggered everytime new command is issued in update)
+func (wc *WidgetConsole) Clear() {
+	// wc.gview.Clear()
+}
+
+// Error print error message to the console output line (second line below prompt)
+func (wc *WidgetConsole) Error(err error) {
+	if wc.gview == nil {
+		return
+	}
+	wc.gview.Autoscroll = true
+	fmt.Fprintf(wc.gview, "%v %v\n\n", colorText("error:", cErrorStr), err.Error())
+}
+
+// Print message to the console output line
+func (wc *WidgetConsole) Print(msg string) {
+	if wc.gview == nil {
+		return
+	}
+	wc.gview.Autoscroll = true
+	if strings.Contains(msg, "\x1b") {
+		// normalize ANSI colors from command output (to be displayed correctly)
+		lines := strings.Split(msg, "\n")
+		sgr := sgrState{}
+		for i, line := range lines {
+			lines[i] = parseAnsi(line, &sgr).render()
+		}
+		msg = strings.Join(lines, "\n")
+	}
+	fmt.Fprint(wc.gview, msg)
+}
+
+// Println prints message to the console output line and add new line at the end
+func (wc *WidgetConsole) Println(msg string) {
+	wc.gview.Autoscroll = true
+	fmt.Fprintln(wc.gview, msg)
+}
+
+// Printf print formatted message to the console output line (second line below prompt)
+func (wc *WidgetConsole) Printf(format string, a ...interface{}) {
+	wc.gview.Autoscroll = true
+	fmt.Fprintf(wc.gview, format, a...) // should there be ... ???
+}
+
+// Keybinds for specific widget
+func (wc *WidgetConsole) Keybinds(g *gocui.Gui) {
+	// setup Tab for autocompletion (because it's global key, so to work in console, overwrite)
+	if err := setKeybinding(g, cmdPrompt, gocui.KeyTab, gocui.ModNone, "complete command or argument", autoComplete); err != nil {
+		log.Panicln(err)
+	}
+	if err := setKeybinding(g, cmdPrompt, gocui.KeyBacktab, gocui.ModNone, "select previous completion", autoCompleteBack); err != nil {
+		log.Panicln(err)
+	}
+	// cancel key
+	if err := setKeybinding(g, cmdPrompt, gocui.KeyCtrlZ, gocui.ModNone, "cancel running command", func(g *gocui.Gui, v *gocui.View) error {
+		if v.Name() == cmdPrompt {
+			wc.Disconnect()
+		}
+		return nil
+	}); err != nil {
+		log.Panicln(err)
+	}
+}
+
+// ExecCmd execute command in the Console Widget
+func (wc *WidgetConsole) ExecCmd(cmd string) {
+	// add to history and update index
+	histErr := wc.addHistory(cmd)
+	if err := commandExecute(wc, cmd); err != nil {
+		wc.Println(promptPS1 + cmd)
+		printResult(wc, err)
+	} else {
+		wc.Println(promptPS1 + cmd)
+	}
+	if histErr != nil {
+		wc.Error(histErr)
+	}
+}
+
+// PrevHistory go back in history and return command from it
+func (wc *WidgetConsole) PrevHistory() string {
+	if len(wc.cmdHistory) > 0 {
+		wc.histIndex--
+		if wc.histIndex >= len(wc.cmdHistory) {
+			wc.histIndex = len(wc.cmdHistory)
+			return ""
+		} else if wc.histIndex < 0 {
+			wc.histIndex = 0
+			return wc.cmdHistory[0]
+		} else {
+			return wc.cmdHistory[wc.histIndex]
+		}
+	}
+	return ""
+}
+
+// NextHistory go forward in history and return command from it
+func (wc *WidgetConsole) NextHistory() string {
+	if len(wc.cmdHistory) > 0 {
+		wc.histIndex++
+		if wc.histIndex >= len(wc.cmdHistory) {
+			wc.histIndex = len(wc.cmdHistory)
+			return ""
+		} else if wc.histIndex < 0 {
+			wc.histIndex = 0
+			return wc.cmdHistory[0]
+		} else {
+			return wc.cmdHistory[wc.histIndex]
+		}
+	}
+	return ""
+}
+
+// ShowConsole is an update function which should be bound to a key
+func showConsole(g *gocui.Gui, v *gocui.View) error {
+	if wc := getConsoleWidget(); wc != nil {
+		if wc.IsHidden() {
+			wc.Enabled = true
+		} else {
+			wc.Disconnect()
+			wc.Enabled = false
+			wc.Layout(g)
+			// check if current view was pointing to this view before (just to be sure!)
+			if g.CurrentView() != nil && g.CurrentView().Name() == cmdPrompt {
+				if wc.lastView != "" {
+					g.SetCurrentView(wc.lastView)
+					wc.lastView = ""
+				} else {
+					setDefaultView(g)
+				}
+			}
+		}
+		return nil
+	}
+	// WTF?? what did I do? Where is my console??
+	return gocui.ErrUnknownView // this error doesn't comfort the new errors in gocui (but whatev)
+}
+
+// Console Editor (special setup for keys)
+func consoleEditor(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	var wc *WidgetConsole
+	if wc = getConsoleWidget(); wc == nil {
+		// if no console widget... wtf are we doing here??
+		return
+	}
+	// completion menu is closed when line is edited
+	compMenu = nil
+	// reverse search in history
+	if histSearch != nil && wc.searchEditor(key, ch, mod) {
+		return
+	}
+
+	switch {
+	case key == gocui.KeyEnter && mod == gocui.ModNone:
+		if wc.editor.continued() {
+			// line ending with backslash continues on the next line
+			wc.editor.insert('\n')
+			break
+		}
+		switch {
+		case wc.conn == nil || !wc.conn.WaitsInput():
+			// command exec
+			wc.ExecCmd(joinLines(wc.editor.String()))
+		case wc.conn.Input(wc.editor.String()):
+			// running command gets the line as input
+			wc.Println(wc.editor.String())
+		default:
+			// command doesn't read its input, line isn't executed as console command
+			wc.Error(errors.New("input of the running command is full (line not sent)"))
+			return
+		}
+		wc.editor.setText("")
+	case key == gocui.KeyArrowDown:
+		// next line or command history
+		if !wc.editor.moveLine(1) {
+			wc.editor.setText(wc.NextHistory())
+		}
+	case key == gocui.KeyArrowUp:
+		// previous line or command history
+		if !wc.editor.moveLine(-1) {
+			wc.editor.setText(wc.PrevHistory())
+		}
+	case key == gocui.KeyCtrlR:
+		wc.startSearch()
+	case key == gocui.KeyCtrlD:
+		// end of input for running command
+		if wc.conn != nil && wc.conn.WaitsInput() {
+			wc.conn.CloseInput()
+			wc.Println(colorText("^D", cConsoleStr))
+		}
+
+	// these are for console output view (not for the actual command line)
+	case key == gocui.KeyPgup:
+		scrollView(wc.gview, -10)
+	case key == gocui.KeyPgdn:
+		scrollView(wc.gview, 10)
+
+	default:
+		wc.editor.handleKey(key, ch, mod)
+	}
+}
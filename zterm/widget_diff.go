@@ -0,0 +1,80 @@
+package zterm
+
+// watchDiff keeps output lines of the previous refresh to detect changes in the current one (like `watch -d`)
+type watchDiff struct {
+	fade int      // number of refreshes a change stays marked after it happened
+	runs int      // number of refreshes done
+	prev []string // lines from previous refresh
+	curr []string // lines from current refresh
+	age  []int    // number of refreshes since the line (by index) changed
+	base []int    // ages at the start of current refresh (to render it again)
+}
+
+// newWatchDiff creates change detection for a widget. Changes are marked also for next `fade` refreshes.
+func newWatchDiff(fade int) *watchDiff {
+	if fade < 0 {
+		fade = 0
+	}
+	return &watchDiff{fade: fade}
+}
+
+// next starts a new refresh (current lines become previous lines)
+func (wd *watchDiff) next() {
+	wd.runs++
+	wd.prev = wd.curr
+	wd.curr = nil
+	wd.base = append([]int{}, wd.age...)
+}
+
+// restart current refresh, when the whole output is rendered again
+func (wd *watchDiff) restart() {
+	wd.curr = nil
+	wd.age = append([]int{}, wd.base...)
+}
+
+// mark compares the line with the line on the same index from the previous refresh.
+//
+// - changed contains true for every rune which is different from the previous refresh
+//
+// - isNew is true if the line didn't exist in the previous refresh
+//
+// - faded is true if the line changed in one of the last `fade` refreshes
+func (wd *watchDiff) mark(line string) (changed []bool, isNew bool, faded bool) {
+	idx := len(wd.curr)
+	wd.curr = append(wd.curr, line)
+	for len(wd.age) <= idx {
+		wd.age = append(wd.age, wd.fade+1)
+	}
+	// first output, nothing to compare with
+	if wd.runs <= 1 {
+		return nil, false, false
+	}
+
+	if idx >= len(wd.prev) {
+		wd.age[idx] = 0
+		return nil, true, false
+	}
+
+	rline, rprev := []rune(line), []rune(wd.prev[idx])
+	changed = make([]bool, len(rline))
+	diff := false
+	for i, r := range rline {
+		if i >= len(rprev) || rprev[i] != r {
+			changed[i] = true
+			diff = true
+		}
+	}
+	// shorter line is a change too
+	if len(rline) < len(rprev) {
+		diff = true
+	}
+
+	if diff {
+		wd.age[idx] = 0
+		return changed, false, false
+	}
+	if wd.age[idx] <= wd.fade {
+		wd.age[idx]++
+	}
+	return nil, false, wd.age[idx] <= wd.fade
+}
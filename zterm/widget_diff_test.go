@@ -0,0 +1,98 @@
+package zterm
+
+import (
+	"reflect"
+	"testing"
+)
+
+// diffMark is result of watchDiff.mark for one line (changed runes as mask like `..x`)
+type diffMark struct {
+	changed string
+	isNew   bool
+	faded   bool
+}
+
+// markRefresh marks all lines of one refresh
+func markRefresh(wd *watchDiff, lines []string) []diffMark {
+	marks := []diffMark{}
+	for _, line := range lines {
+		changed, isNew, faded := wd.mark(line)
+		mask := ""
+		for _, c := range changed {
+			if c {
+				mask += "x"
+			} else {
+				mask += "."
+			}
+		}
+		marks = append(marks, diffMark{mask, isNew, faded})
+	}
+	return marks
+}
+
+func TestWatchDiff(t *testing.T) {
+	type refresh struct {
+		lines []string
+		want  []diffMark
+	}
+	tests := []struct {
+		name      string
+		fade      int
+		refreshes []refresh
+	}{
+		{"changes", 0, []refresh{
+			{[]string{"JOB1 ACTIVE", "JOB2 WAIT"}, []diffMark{{}, {}}},
+			{[]string{"JOB1 ACTIVE", "JOB2 DONE", "JOB3 WAIT"}, []diffMark{{}, {".....xxxx", false, false}, {"", true, false}}},
+			{[]string{"JOB1 ACTIVE", "JOB2 DONE", "JOB3 WAIT"}, []diffMark{{}, {}, {}}},
+		}},
+		{"shorter line", 0, []refresh{
+			{[]string{"RC=0012"}, []diffMark{{}}},
+			{[]string{"RC=0"}, []diffMark{{"....", false, false}}},
+			{[]string{"RC=04"}, []diffMark{{"....x", false, false}}},
+		}},
+		{"wide characters", 0, []refresh{
+			{[]string{"日本 ok"}, []diffMark{{}}},
+			{[]string{"日本 ko"}, []diffMark{{"...xx", false, false}}},
+		}},
+		{"fade", 2, []refresh{
+			{[]string{"a", "b"}, []diffMark{{}, {}}},
+			{[]string{"a", "c"}, []diffMark{{}, {"x", false, false}}},
+			{[]string{"a", "c"}, []diffMark{{}, {"", false, true}}},
+			{[]string{"a", "c"}, []diffMark{{}, {"", false, true}}},
+			{[]string{"a", "c"}, []diffMark{{}, {}}},
+		}},
+	}
+	for _, tt := range tests {
+		wd := newWatchDiff(tt.fade)
+		for i, r := range tt.refreshes {
+			wd.next()
+			if got := markRefresh(wd, r.lines); !reflect.DeepEqual(got, r.want) {
+				t.Errorf("%v: refresh %d: mark = %v, want %v", tt.name, i+1, got, r.want)
+			}
+		}
+	}
+}
+
+func TestWatchDiffRestart(t *testing.T) {
+	wd := newWatchDiff(1)
+	for _, lines := range [][]string{{"a", "b"}, {"a", "c"}} {
+		wd.next()
+		markRefresh(wd, lines)
+	}
+	wd.next()
+	first := markRefresh(wd, []string{"x", "c"})
+	// output rendered again (e.g. with other highlighting) is marked the same way
+	wd.restart()
+	if again := markRefresh(wd, []string{"x", "c"}); !reflect.DeepEqual(again, first) {
+		t.Errorf("mark after restart = %v, want %v", again, first)
+	}
+	if want := []diffMark{{"x", false, false}, {"", false, true}}; !reflect.DeepEqual(first, want) {
+		t.Errorf("mark = %v, want %v", first, want)
+	}
+}
+
+func TestWatchDiffNegativeFade(t *testing.T) {
+	if wd := newWatchDiff(-3); wd.fade != 0 {
+		t.Errorf("fade = %d, want 0", wd.fade)
+	}
+}
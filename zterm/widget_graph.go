@@ -0,0 +1,355 @@
+package zterm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// GraphConfig configuration of the graph rendering for a view (`render: graph`)
+type GraphConfig struct {
+	Regex   string `mapstructure:"regex,omitempty" yaml:"regex,omitempty"`     // each capture group (or whole match) is a series
+	JSON    string `mapstructure:"json,omitempty" yaml:"json,omitempty"`       // json paths separated by comma (like `cpu.usage,items.0.value`)
+	Style   string `mapstructure:"style,omitempty" yaml:"style,omitempty"`     // block (default), braille or line
+	History int    `mapstructure:"history,omitempty" yaml:"history,omitempty"` // number of values kept for each series
+}
+
+// graphSeries is a rolling history of values
+type graphSeries struct {
+	name   string
+	values []float64
+}
+
+// WidgetGraph structure for rendering numeric values from the output as graph in the WidgetStack
+type WidgetGraph struct {
+	Widget
+	stack   *WidgetStack
+	conf    GraphConfig
+	re      *regexp.Regexp
+	raw     string // output of current run
+	series  []*graphSeries
+	pending bool // current run didn't add value yet
+}
+
+var (
+	sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+	// braille dots for left and right column, from bottom to top
+	brailleLeft  = []rune{0x40, 0x04, 0x02, 0x01}
+	brailleRight = []rune{0x80, 0x20, 0x10, 0x08}
+)
+
+// NewWidgetGraph creates a graph widget which extracts numbers from output sent to it and display their history in the stack widget.
+func NewWidgetGraph(ws *WidgetStack, conf GraphConfig) (*WidgetGraph, error) {
+	wg := &WidgetGraph{Widget: Widget{name: ws.name + "-graph", Enabled: true}, stack: ws, conf: conf}
+	if wg.conf.History <= 0 {
+		wg.conf.History = 200
+	}
+	if conf.Regex != "" {
+		re, err := regexp.Compile(conf.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("graph regex: %v", err)
+		}
+		wg.re = re
+	} else if conf.JSON == "" {
+		// by default look for the first number
+		wg.re = numberFindRe
+	}
+	return wg, nil
+}
+
+var numberFindRe = regexp.MustCompile(`[-+]?[0-9]*\.?[0-9]+`)
+
+// Layout renders graph again when size of the stack widget changed
+func (wg *WidgetGraph) Layout(g *gocui.Gui) error {
+	if wg.stack.gview == nil {
+		return nil
+	}
+	if w, h := wg.stack.gview.Size(); w != wg.width || h != wg.height {
+		wg.width, wg.height = w, h
+		wg.stack.reprint(wg.render())
+	}
+	return nil
+}
+
+// Clear starts new run for the graph (new values will be added)
+func (wg *WidgetGraph) Clear() {
+	wg.raw = ""
+	wg.pending = true
+}
+
+// Print append a text to the output of current run, extract values and display graph in the stack widget
+func (wg *WidgetGraph) Print(str string) {
+	wg.raw += str
+	values, names := wg.extract()
+	if len(values) == 0 {
+		return
+	}
+	for i, val := range values {
+		if i >= len(wg.series) {
+			wg.series = append(wg.series, &graphSeries{name: names[i]})
+		}
+		s := wg.series[i]
+		if wg.pending || len(s.values) == 0 {
+			s.values = append(s.values, val)
+		} else {
+			// more output in the same run, update the value
+			s.values[len(s.values)-1] = val
+		}
+		if len(s.values) > wg.conf.History {
+			s.values = s.values[len(s.values)-wg.conf.History:]
+		}
+	}
+	wg.pending = false
+	wg.stack.reprint(wg.render())
+}
+
+// Connect content producing channel to the stack widget
+func (wg *WidgetGraph) Connect(conn *RecvConn) {
+	wg.stack.Connect(conn)
+}
+
+// Disconnect content producing channel from the stack widget
+func (wg *WidgetGraph) Disconnect() {
+	wg.stack.Disconnect()
+}
+
+// Error append an error text to the stack widget
+func (wg *WidgetGraph) Error(err error) {
+	wg.stack.Error(err)
+}
+
+// extract values from the output of current run (by regex or json path)
+func (wg *WidgetGraph) extract() (values []float64, names []string) {
+	text := parseAnsi(wg.raw, nil).String()
+	if wg.conf.JSON != "" {
+		var data interface{}
+		if err := json.Unmarshal([]byte(text), &data); err != nil {
+			return nil, nil
+		}
+		for _, path := range strings.Split(wg.conf.JSON, ",") {
+			path = strings.TrimSpace(path)
+			val, ok := jsonPathValue(data, path)
+			if !ok {
+				return nil, nil
+			}
+			values = append(values, val)
+			names = append(names, path)
+		}
+		return
+	}
+
+	match := wg.re.FindStringSubmatch(text)
+	if match == nil {
+		return nil, nil
+	}
+	groups := match
+	if len(match) > 1 {
+		groups = match[1:]
+	}
+	subnames := wg.re.SubexpNames()
+	for i, g := range groups {
+		val, ok := parseNumber(g)
+		if !ok {
+			return nil, nil
+		}
+		name := "value"
+		if len(match) > 1 {
+			name = subnames[i+1]
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+		}
+		values = append(values, val)
+		names = append(names, name)
+	}
+	return
+}
+
+// jsonPathValue returns number from json data specified by dotted path (array items by index, like `items.0.value`)
+func jsonPathValue(data interface{}, path string) (float64, bool) {
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			continue
+		}
+		switch d := data.(type) {
+		case map[string]interface{}:
+			data = d[key]
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(d) {
+				return 0, false
+			}
+			data = d[idx]
+		default:
+			return 0, false
+		}
+	}
+	switch v := data.(type) {
+	case float64:
+		return v, true
+	case string:
+		return parseNumber(v)
+	}
+	return 0, false
+}
+
+// stats returns minimum, maximum, average and last value of the series
+func (s *graphSeries) stats() (min, max, avg, last float64) {
+	if len(s.values) == 0 {
+		return
+	}
+	min, max = math.Inf(1), math.Inf(-1)
+	for _, v := range s.values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+		avg += v
+	}
+	avg /= float64(len(s.values))
+	last = s.values[len(s.values)-1]
+	return
+}
+
+// render returns graph of all series as text
+func (wg *WidgetGraph) render() string {
+	if len(wg.series) == 0 || wg.width <= 0 {
+		return ""
+	}
+	var sb strings.Builder
+	// split height between series (label line + graph)
+	chartHeight := wg.height/len(wg.series) - 1
+	for _, s := range wg.series {
+		min, max, avg, last := s.stats()
+		sb.WriteString(fmt.Sprintf("%v %v  min %v  max %v  avg %v\n",
+			colorText(s.name, cHighlightStr), formatValue(last), formatValue(min), formatValue(max), formatValue(avg)))
+		switch wg.conf.Style {
+		case "braille":
+			sb.WriteString(sparkBraille(s.values, wg.width, min, max) + "\n")
+		case "line":
+			sb.WriteString(lineChart(s.values, wg.width, chartHeight, min, max))
+		default:
+			sb.WriteString(sparkBlock(s.values, wg.width, min, max) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// formatValue formats number to be short (max 2 decimal places)
+func formatValue(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// scale value between min and max into number of levels (0 - levels-1)
+func scale(v, min, max float64, levels int) int {
+	if max <= min {
+		return 0
+	}
+	l := int(math.Round((v - min) / (max - min) * float64(levels-1)))
+	if l < 0 {
+		return 0
+	}
+	if l >= levels {
+		return levels - 1
+	}
+	return l
+}
+
+// lastValues returns last n values
+func lastValues(values []float64, n int) []float64 {
+	if len(values) > n {
+		return values[len(values)-n:]
+	}
+	return values
+}
+
+// sparkBlock draws sparkline with block characters (one value per character)
+func sparkBlock(values []float64, width int, min, max float64) string {
+	var sb strings.Builder
+	for _, v := range lastValues(values, width) {
+		sb.WriteRune(sparkBlocks[scale(v, min, max, len(sparkBlocks))])
+	}
+	return sb.String()
+}
+
+// sparkBraille draws sparkline with braille characters (two values per character)
+func sparkBraille(values []float64, width int, min, max float64) string {
+	values = lastValues(values, width*2)
+	var sb strings.Builder
+	for i := 0; i < len(values); i += 2 {
+		r := rune(0x2800)
+		// fill the column from bottom up to the value
+		for l := 0; l <= scale(values[i], min, max, 4); l++ {
+			r |= brailleLeft[l]
+		}
+		if i+1 < len(values) {
+			for l := 0; l <= scale(values[i+1], min, max, 4); l++ {
+				r |= brailleRight[l]
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// lineChart draws line chart with braille characters into area of width x height characters
+func lineChart(values []float64, width int, height int, min, max float64) string {
+	if height < 1 {
+		height = 1
+	}
+	// y-axis labels
+	maxLabel, minLabel := formatValue(max), formatValue(min)
+	labelWidth := len(maxLabel)
+	if len(minLabel) > labelWidth {
+		labelWidth = len(minLabel)
+	}
+	width -= labelWidth + 1
+	if width < 1 {
+		return ""
+	}
+	values = lastValues(values, width*2)
+	canvas := make([][]rune, height)
+	for y := range canvas {
+		canvas[y] = make([]rune, width)
+		for x := range canvas[y] {
+			canvas[y][x] = 0x2800
+		}
+	}
+	dots := height * 4
+	prev := -1
+	for i, v := range values {
+		dy := scale(v, min, max, dots)
+		// connect with previous point vertically
+		from, to := dy, dy
+		if prev >= 0 {
+			if prev < dy {
+				from = prev + 1
+			} else if prev > dy {
+				to = prev - 1
+			}
+		}
+		for d := from; d <= to; d++ {
+			row := height - 1 - d/4
+			if i%2 == 0 {
+				canvas[row][i/2] |= brailleLeft[d%4]
+			} else {
+				canvas[row][i/2] |= brailleRight[d%4]
+			}
+		}
+		prev = dy
+	}
+	var sb strings.Builder
+	for y, line := range canvas {
+		label := ""
+		if y == 0 {
+			label = maxLabel
+		} else if y == height-1 {
+			label = minLabel
+		}
+		sb.WriteString(fmt.Sprintf("%*s ", labelWidth, label) + string(line) + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,121 @@
+package zterm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraphExtract(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   GraphConfig
+		output string
+		values []float64
+		names  []string
+	}{
+		{"first number", GraphConfig{}, "spool usage: 85.5% of 12 volumes", []float64{85.5}, []string{"value"}},
+		{"ansi", GraphConfig{}, "\x1b[31m-3\x1b[0m errors", []float64{-3}, []string{"value"}},
+		{"no number", GraphConfig{}, "no data", nil, nil},
+		{"whole match", GraphConfig{Regex: `\d+ jobs`}, "queue: 42 jobs", []float64{42}, []string{"value"}},
+		{"groups", GraphConfig{Regex: `cpu (\S+) mem (\S+)`}, "cpu 12.5% mem 40%", []float64{12.5, 40}, []string{"#1", "#2"}},
+		{"named groups", GraphConfig{Regex: `in=(?P<in>\d+) out=(?P<out>\d+)`}, "in=10 out=7", []float64{10, 7}, []string{"in", "out"}},
+		{"group not a number", GraphConfig{Regex: `rc=(\S+)`}, "rc=none", nil, nil},
+		{"json", GraphConfig{JSON: "cpu.usage, items.1.value"}, `{"cpu": {"usage": 0.75}, "items": [{"value": 1}, {"value": "20MB"}]}`,
+			[]float64{0.75, 20}, []string{"cpu.usage", "items.1.value"}},
+		{"json missing path", GraphConfig{JSON: "cpu.usage,mem"}, `{"cpu": {"usage": 1}}`, nil, nil},
+		{"incomplete json", GraphConfig{JSON: "cpu"}, `{"cpu": 1`, nil, nil},
+	}
+	for _, tt := range tests {
+		wg, err := NewWidgetGraph(&WidgetStack{}, tt.conf)
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		wg.raw = tt.output
+		values, names := wg.extract()
+		if !reflect.DeepEqual(values, tt.values) || !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("%v: extract = %v %q, want %v %q", tt.name, values, names, tt.values, tt.names)
+		}
+	}
+}
+
+func TestGraphInvalidRegex(t *testing.T) {
+	if _, err := NewWidgetGraph(&WidgetStack{}, GraphConfig{Regex: "(cpu"}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestJSONPathValue(t *testing.T) {
+	data := map[string]interface{}{
+		"a":     map[string]interface{}{"b": 2.5},
+		"items": []interface{}{1.0, "3%", true},
+	}
+	tests := []struct {
+		path string
+		want float64
+		ok   bool
+	}{
+		{"a.b", 2.5, true},
+		{".a..b", 2.5, true},
+		{"items.0", 1, true},
+		{"items.1", 3, true},
+		{"items.2", 0, false},
+		{"items.3", 0, false},
+		{"items.-1", 0, false},
+		{"items.x", 0, false},
+		{"a", 0, false},
+		{"a.b.c", 0, false},
+		{"missing", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := jsonPathValue(data, tt.path); got != tt.want || ok != tt.ok {
+			t.Errorf("jsonPathValue(%q) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGraphStats(t *testing.T) {
+	s := &graphSeries{values: []float64{4, -2, 10, 8}}
+	min, max, avg, last := s.stats()
+	if min != -2 || max != 10 || avg != 5 || last != 8 {
+		t.Errorf("stats = %v %v %v %v, want -2 10 5 8", min, max, avg, last)
+	}
+	if min, max, avg, last := (&graphSeries{}).stats(); min != 0 || max != 0 || avg != 0 || last != 0 {
+		t.Errorf("stats of empty series = %v %v %v %v, want zeros", min, max, avg, last)
+	}
+}
+
+func TestGraphHistory(t *testing.T) {
+	wg, _ := NewWidgetGraph(&WidgetStack{}, GraphConfig{History: 3})
+	// render is skipped without size
+	for _, out := range []string{"1", "2", "3", "4"} {
+		wg.Clear()
+		wg.Print(out)
+	}
+	// more output in the same run updates the last value
+	wg.Print("5")
+	if want := []float64{2, 3, 45}; !reflect.DeepEqual(wg.series[0].values, want) {
+		t.Errorf("values = %v, want %v", wg.series[0].values, want)
+	}
+}
+
+func TestSparklines(t *testing.T) {
+	values := []float64{0, 1, 2, 3, 4, 5, 6, 7}
+	if got, want := sparkBlock(values, 10, 0, 7), "▁▂▃▄▅▆▇█"; got != want {
+		t.Errorf("sparkBlock = %q, want %q", got, want)
+	}
+	if got, want := sparkBlock(values, 3, 0, 7), "▆▇█"; got != want {
+		t.Errorf("sparkBlock of last values = %q, want %q", got, want)
+	}
+	if got, want := sparkBlock([]float64{5, 5}, 3, 5, 5), "▁▁"; got != want {
+		t.Errorf("sparkBlock of constant values = %q, want %q", got, want)
+	}
+	if got, want := sparkBraille([]float64{0, 3, 1}, 5, 0, 3), "⣸⡄"; got != want {
+		t.Errorf("sparkBraille = %q, want %q", got, want)
+	}
+	for v, want := range map[float64]string{1.005: "1", 2.456: "2.46", -0.5: "-0.5", 100: "100"} {
+		if got := formatValue(v); got != want {
+			t.Errorf("formatValue(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
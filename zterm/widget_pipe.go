@@ -1,8 +1,10 @@
 package zterm
 
 import (
-	"fmt"
+	"path"
+	"strings"
 
+	"github.com/alecthomas/chroma/lexers"
 	"github.com/alecthomas/chroma/quick"
 	"github.com/awesome-gocui/gocui"
 )
@@ -11,11 +13,16 @@ import (
 type WidgetPipe struct {
 	Widget
 	pipedWidget Widgeter
+	lexer       string
 }
 
 // NewWidgetPipe creates a pipe widget wich is used as a pipe between real widget and output sent to it.
-func NewWidgetPipe(w Widgeter) *WidgetPipe {
-	return &WidgetPipe{Widget: Widget{name: "pipe", Enabled: true}, pipedWidget: w}
+// Lexer is used for syntax highlighting, if empty, it's detected from the file name in the command or from the output.
+func NewWidgetPipe(w Widgeter, lexer string, command string) *WidgetPipe {
+	if lexer == "" {
+		lexer = matchLexer(command)
+	}
+	return &WidgetPipe{Widget: Widget{name: "pipe", Enabled: true}, pipedWidget: w, lexer: lexer}
 }
 
 // Layout setup for floaty widget
@@ -27,19 +34,106 @@ func (wp *WidgetPipe) Layout(g *gocui.Gui) error {
 	return nil
 }
 
-// Print append a text to the widget content
+// Connect content producing channel to the piped widget
+func (wp *WidgetPipe) Connect(conn *RecvConn) {
+	if wp.pipedWidget != nil {
+		wp.pipedWidget.Connect(conn)
+	}
+}
+
+// Disconnect content producing channel from the piped widget
+func (wp *WidgetPipe) Disconnect() {
+	if wp.pipedWidget != nil {
+		wp.pipedWidget.Disconnect()
+	}
+}
+
+// Clear clears the piped widget
+func (wp *WidgetPipe) Clear() {
+	if wp.pipedWidget != nil {
+		wp.pipedWidget.Clear()
+	}
+}
+
+// Print append a highlighted text to the piped widget content
 func (wp *WidgetPipe) Print(str string) {
 	if wp.pipedWidget != nil {
-		// fmt.Fprint(wp.pipedWidget.GetView(), str)
-		// quick.Highlight(wp.pipedWidget.GetView(), str, mylexer, "terminal16m", "monokai")
-		// lexers.Register()
-		quick.Highlight(wp.pipedWidget.GetView(), str, "go", "terminal256", "monokai")
+		if wp.lexer == "" {
+			// detect from content (only first output, then keep it)
+			if l := lexers.Analyse(str); l != nil {
+				wp.lexer = l.Config().Name
+			} else {
+				wp.lexer = "plaintext"
+			}
+		}
+		var sb strings.Builder
+		if err := quick.Highlight(&sb, str, wp.lexer, fancyFormatter(), fancyStyle()); err != nil {
+			wp.pipedWidget.Print(str)
+			return
+		}
+		wp.pipedWidget.Print(sb.String())
 	}
 }
 
 // Error append an error text to the widget content
 func (wp *WidgetPipe) Error(err error) {
 	if wp.pipedWidget != nil {
-		fmt.Fprintln(wp.pipedWidget.GetView(), colorText("error:", cErrorStr), err.Error())
+		wp.pipedWidget.Error(err)
+	}
+}
+
+// parseFancy checks if command is fancy command (`fancy <cmd>` or `fancy:<lexer> <cmd>`)
+// and returns lexer name (can be empty) and the command without fancy prefix.
+func parseFancy(command string) (lexer string, cmd string, ok bool) {
+	command = strings.TrimSpace(command)
+	parts := strings.SplitN(command, " ", 2)
+	if parts[0] != "fancy" && !strings.HasPrefix(parts[0], "fancy:") {
+		return "", command, false
+	}
+	lexer = strings.TrimPrefix(strings.TrimPrefix(parts[0], "fancy"), ":")
+	if len(parts) > 1 {
+		cmd = strings.TrimSpace(parts[1])
+	}
+	return lexer, cmd, true
+}
+
+// matchLexer finds lexer by file name (extension) used in the command arguments
+func matchLexer(command string) string {
+	args := strings.Fields(command)
+	// search from the end, file is usually the last argument
+	for i := len(args) - 1; i > 0; i-- {
+		arg := strings.Trim(args[i], "\"'")
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if isDsn(arg) {
+			// dataset name, use low level qualifier (like //'USER.JCL(MYJOB)' -> *.jcl)
+			dsn := strings.Trim(strings.TrimLeft(arg, "/"), "'")
+			dsn = strings.Split(dsn, "(")[0]
+			arg = "x." + strings.ToLower(dsn[strings.LastIndex(dsn, ".")+1:])
+		}
+		if l := lexers.Match(path.Base(arg)); l != nil {
+			return l.Config().Name
+		}
+	}
+	return ""
+}
+
+// fancyFormatter returns chroma formatter based on color-space from theme
+func fancyFormatter() string {
+	switch config.Theme.ColorSpace {
+	case "basic":
+		return "terminal16"
+	case "truecolor":
+		return "terminal16m"
+	}
+	return "terminal256"
+}
+
+// fancyStyle returns chroma style from theme (monokai as default)
+func fancyStyle() string {
+	if config.Theme.FancyStyle != "" {
+		return config.Theme.FancyStyle
 	}
+	return "monokai"
 }
@@ -1,302 +1,524 @@
-package zterm
-
-import (
-	"errors"
-	"fmt"
-	"log"
-	"strings"
-	"time"
-
-	"github.com/awesome-gocui/gocui"
-)
-
-// WidgetStack structure for GUI (widgets which are stack on each other)
-type WidgetStack struct {
-	Widget
-	pos       int
-	stopFun   chan bool
-	Fun       func() error
-	funStr    string
-	refresh   time.Duration
-	highlight map[string]bool
-}
-
-// NewWidgetStack creates a widget for stack GUI
-func NewWidgetStack(name string, pos int, height int, body string) *WidgetStack {
-	return &WidgetStack{Widget: Widget{name: name, body: body, width: 0, height: height, Enabled: true}, pos: pos,
-		refresh: 5 * time.Second, stopFun: make(chan bool, 1)}
-}
-
-// Layout setup for widget
-func (ws *WidgetStack) Layout(g *gocui.Gui) error {
-	// do not display if disabled
-	if !ws.Enabled {
-		g.DeleteView(ws.name) // if doesn't exist, don't care
-		ws.gview = nil
-		return nil
-	}
-	// Enabled, display...
-	maxX, maxY := g.Size()
-	yHeight := maxY - 1 // initial height is 100%
-	if ws.height > 0 {
-		yHeight = maxY * ws.height / viewMaxSize
-	}
-	yPos := 0
-	for _, wl := range getSortedWidgetStack() {
-		if wl.Position() < ws.pos {
-			yPos += maxY * wl.height / viewMaxSize
-		} else {
-			break
-		}
-	}
-	// adjust height to maximum if it is last view
-	if ws.pos == viewLastPos && yPos+yHeight < maxY {
-		yHeight = maxY - yPos - 1
-	}
-
-	// save for floaty ;)
-	ws.x0 = 0
-	ws.y0 = yPos
-	ws.x1 = maxX - 1
-	ws.y1 = yPos + yHeight
-	// overlap, for first in stack, set to 0 (so it looks good :)
-	var overlap byte = 1
-	if ws.pos == viewFirstPos {
-		overlap = 0
-	}
-	// set view position and dimension
-	v, err := g.SetView(ws.name, 0, yPos, maxX-1, yPos+yHeight, overlap)
-	if err != nil {
-		if !errors.Is(err, gocui.ErrUnknownView) {
-			return fmt.Errorf("view %v: %v", ws.name, err)
-		}
-		fmt.Fprint(v, ws.body)
-	}
-	ws.gview = v // set pointer to GUI View
-	v.FrameColor = cFrame
-	if g.CurrentView() == nil {
-		g.SetCurrentView(ws.name)
-	}
-
-	// set title
-	if g.CurrentView() == v {
-		v.TitleColor = cFrameSel
-		v.Title = fmt.Sprintf("[ %v ]", ws.name)
-	} else {
-		v.TitleColor = cFrame
-		v.Title = fmt.Sprintf("| %v |", ws.name)
-	}
-	v.Autoscroll = true
-	return nil
-}
-
-// Keybinds for specific widget
-func (ws *WidgetStack) Keybinds(g *gocui.Gui) {
-	// special keybinds for the widgets
-	// change refresh rate
-	if err := g.SetKeybinding(ws.name, gocui.KeyCtrlR, gocui.ModNone, changeRefresh); err != nil {
-		log.Panicln(err)
-	}
-	// cancel key
-	if err := g.SetKeybinding(ws.name, gocui.KeyCtrlZ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		if v.Name() == ws.name {
-			ws.StopFun()
-		}
-		return nil
-	}); err != nil {
-		log.Panicln(err)
-	}
-}
-
-// Position returns position in the stack of widgets
-func (ws *WidgetStack) Position() int {
-	return ws.pos
-}
-
-// Print append a text to the widget content.
-// Printed line or word will be highlighted if such word exist in `highlight` map in WidgetStack.
-func (ws *WidgetStack) Print(str string) {
-	if ws.gview != nil {
-		ws.gview.Autoscroll = true
-		if ws.highlight != nil && len(ws.highlight) > 0 {
-			// remove last new line
-			if str[len(str)-1] == '\n' {
-				str = str[:len(str)-1]
-			}
-			lines := strings.Split(str, "\n")
-			for _, line := range lines {
-				written := false
-				for sub, hiLine := range ws.highlight {
-					if strings.Contains(line, sub) {
-						if hiLine {
-							// highlight full line
-							ws.body += colorText(line, cHighlightStr) + "\n"
-							fmt.Fprintln(ws.gview, colorText(line, cHighlightStr))
-							written = true
-							break
-						}
-						// highlight word only
-						ws.body += strings.ReplaceAll(line, sub, colorText(sub, cHighlightStr)) + "\n"
-						fmt.Fprintln(ws.gview, strings.ReplaceAll(line, sub, colorText(sub, cHighlightStr)))
-						written = true
-						break
-					}
-				}
-				// write normal (if not in highlight)
-				if !written {
-					ws.body += line + "\n"
-					fmt.Fprintln(ws.gview, line)
-				}
-			}
-		} else {
-			// write full text if no highlight map
-			ws.body += str
-			fmt.Fprint(ws.gview, str)
-		}
-	}
-}
-
-// SetupFun set function to run in interval in this widget
-func (ws *WidgetStack) SetupFun(cmd string) {
-	if len(cmd) == 0 {
-		return
-	}
-
-	var wout Widgeter = ws
-	realcmd := strings.TrimSpace(cmd)
-	if strings.HasPrefix(realcmd, "fancy ") {
-		realcmd = strings.TrimSpace(strings.TrimPrefix(realcmd, "fancy "))
-		wout = NewWidgetPipe(ws)
-	}
-
-	if strings.HasPrefix(realcmd, "remote") {
-		ws.Fun = func() error {
-			return cmdSSH(wout, strings.TrimPrefix(realcmd, "remote"))
-		}
-	} else {
-		ws.Fun = func() error {
-			return cmdShell(wout, realcmd)
-		}
-	}
-	ws.StartFun()
-}
-
-// GetFunString return command running in this widget
-func (ws *WidgetStack) GetFunString() string {
-	return ws.funStr
-}
-
-// StartFun starts a function for the view to update it's content.
-// Function has to return string which is used for update
-func (ws *WidgetStack) StartFun() {
-	// check if function is set
-	if ws.Fun == nil {
-		return
-	}
-
-	// setup goroutine
-	go func() {
-		// setup action function
-		action := func() error {
-			if err := ws.Fun(); err != nil {
-				appendErrorMsgToView(ws, err)
-				return err
-			}
-			return nil
-		}
-		// run it for the first time
-		acterr := action()
-
-		for {
-			// To make it possible to kill the Fun, we need to listen to 2 different channels
-			// one for stopFun and one for timeout, which would start the action again
-			sleepTime := make(chan struct{})
-			// sleeping goroutine
-			go func() {
-				<-time.After(ws.refresh)
-				close(sleepTime)
-			}()
-			if acterr == nil {
-				select {
-				case <-ws.stopFun:
-					ws.Disconnect() // disconnect content channel
-					// w.conn.Stop()
-					return
-				case <-ws.conn.IsEnd():
-				}
-			}
-			select {
-			case <-ws.stopFun:
-				ws.Disconnect()
-				return
-			case <-sleepTime:
-			}
-			acterr = action()
-		}
-	}()
-}
-
-// StopFun stops function running to update widget
-func (ws *WidgetStack) StopFun() {
-	if ws.Fun == nil {
-		return
-	}
-	select {
-	case ws.stopFun <- true:
-	default:
-		// channel is full, screw another write...
-	}
-}
-
-// Change refresh rate of the widget content (Fun stuff)
-func changeRefresh(g *gocui.Gui, v *gocui.View) error {
-	if v == nil {
-		return nil
-	}
-	if ws := getWidgetStack(v.Name()); ws != nil {
-		ws.StopFun()
-		switch ws.refresh {
-		case 2 * time.Second:
-			ws.refresh = 5 * time.Second
-		case 5 * time.Second:
-			ws.refresh = 10 * time.Second
-		case 10 * time.Second:
-			ws.refresh = 2 * time.Second
-		}
-		ws.StartFun()
-		// add notification pop-up
-		if wf, err := addSimplePopupWidget("refresh-popup", cPopup, ws.x0+1, ws.y1-4, ws.x1-2, 3,
-			fmt.Sprintf("refresh interval changed to %v", ws.refresh)); err == nil {
-			// with CtrlR keybind to refresh THIS view (widget, not widget-floaty)
-			g.DeleteKeybinding(wf.name, gocui.KeyCtrlR, gocui.ModNone) // don't care about errors (just to not duplicate it)
-			if err := g.SetKeybinding(wf.name, gocui.KeyCtrlR, gocui.ModNone,
-				func(g *gocui.Gui, v *gocui.View) error {
-					nv, err := g.View(ws.GetName())
-					if err != nil {
-						return err
-					}
-					return changeRefresh(g, nv)
-				}); err != nil {
-				log.Panicln(err)
-			}
-			// with KeyTab keybind to change to NEXT view directly (widget, not widget-floaty)
-			g.DeleteKeybinding(wf.name, gocui.KeyTab, gocui.ModNone) // don't care about errors (just to not duplicate it)
-			if err := g.SetKeybinding(wf.name, gocui.KeyTab, gocui.ModNone,
-				func(g *gocui.Gui, v *gocui.View) error {
-					nv, err := g.View(ws.GetName())
-					if err != nil {
-						return err
-					}
-					// close this floaty
-					closeFloatyWidget(g, v)
-					// change ;)
-					changeView(g, nv)
-					return nil
-				}); err != nil {
-				log.Panicln(err)
-			}
-
-		}
-	}
-	return nil
-}
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// WidgetStack structure for GUI (widgets which are stack on each other)
+type WidgetStack struct {
+	Widget
+	pos        int
+	stopFun    chan bool
+	Fun        func() error
+	funStr     string
+	refresh    time.Duration
+	schedule   *cronSchedule // refresh only when the time matches the schedule (nil refreshes always)
+	paused     bool          // job is stopped by user
+	job        *jobOptions   // directory, environment, shell and timeout of the job
+	status     jobStatus     // result of the last job run
+	alerts     *viewAlerts   // alert rules matching the output (nil without rules)
+	flashUntil time.Time     // frame flashes after alert
+	flashStop  chan struct{} // stops redrawing of the flashing frame (nil when it doesn't flash)
+	highlight  map[string]bool
+	diff       *watchDiff
+	stripAnsi  bool
+	sgr        sgrState // ANSI style continuing from previous line
+	render     Widgeter // output rendering (table or graph), nil for text
+	workspace  string
+	prevBody   string // output of previous refresh (for workspace activity)
+}
+
+// NewWidgetStack creates a widget for stack GUI
+func NewWidgetStack(name string, pos int, height int, body string) *WidgetStack {
+	return &WidgetStack{Widget: Widget{name: name, body: body, width: 0, height: height, Enabled: true}, pos: pos,
+		refresh: defaultRefresh(), stopFun: make(chan bool, 1)}
+}
+
+// Layout setup for widget
+func (ws *WidgetStack) Layout(g *gocui.Gui) error {
+	// disabled view is not in layout (but it's still updated)
+	r, ok := layoutRects[ws.name]
+	if !ok && ws.Enabled && ws.workspace == currWorkspace.name && zoomedView == "" {
+		// not arranged yet (new view)
+		arrangeLayout(g)
+		r, ok = layoutRects[ws.name]
+	}
+	if !ok {
+		// not displayed (disabled, other workspace or zoom), view is hidden out of the screen to keep collecting the output
+		maxX, maxY := g.Size()
+		r = layoutRect{x0: -maxX, y0: 0, x1: -1, y1: maxY - 1}
+	}
+
+	// save for floaty ;)
+	ws.x0 = r.x0
+	ws.y0 = r.y0
+	ws.x1 = r.x1
+	ws.y1 = r.y1
+	// set view position and dimension
+	v, err := g.SetView(ws.name, r.x0, r.y0, r.x1, r.y1, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) {
+			return fmt.Errorf("view %v: %v", ws.name, err)
+		}
+		fmt.Fprint(v, ws.body)
+		// text follows new output (scrolling turns it off until next output), rendered output (table or graph)
+		// keeps scroll position as it's printed again with every change
+		v.Autoscroll = ws.render == nil
+	}
+	// overlap shared borders with views above and on the left (so it looks good :)
+	v.Overlaps = r.overlaps
+	v.Visible = ok
+	ws.gview = v // set pointer to GUI View
+	v.FrameColor = cFrame
+	if g.CurrentView() == nil {
+		g.SetCurrentView(ws.name)
+	}
+
+	// set title
+	name := ws.name
+	if zoomedView == ws.name {
+		name += " (zoom)"
+	}
+	switch {
+	case ws.paused && ws.Fun != nil:
+		name += " (paused)"
+	case ws.schedule != nil && !ws.schedule.matches(time.Now()):
+		name += " (off schedule)"
+	}
+	if badge := ws.status.badge(); badge != "" {
+		name += " " + badge
+	}
+	if g.CurrentView() == v {
+		v.TitleColor = cFrameSel
+		v.Title = fmt.Sprintf("[ %v ]", name)
+	} else {
+		v.TitleColor = cFrame
+		v.Title = fmt.Sprintf("| %v |", name)
+	}
+	if ws.flashing() {
+		v.FrameColor = cError
+		v.TitleColor = cError
+	}
+	// layout of output rendering (fixed table header, graph size)
+	if ws.render != nil {
+		return ws.render.Layout(g)
+	}
+	return nil
+}
+
+// Keybinds for specific widget
+func (ws *WidgetStack) Keybinds(g *gocui.Gui) {
+	// special keybinds for the widgets
+	// change refresh rate
+	if err := setKeybinding(g, ws.name, gocui.KeyCtrlR, gocui.ModNone, "change refresh interval (2s, 5s, 10s)", changeRefresh); err != nil {
+		log.Panicln(err)
+	}
+	// cancel key
+	if err := setKeybinding(g, ws.name, gocui.KeyCtrlZ, gocui.ModNone, "pause or resume refreshing", func(g *gocui.Gui, v *gocui.View) error {
+		if v.Name() == ws.name {
+			ws.SetPaused(!ws.paused)
+		}
+		return nil
+	}); err != nil {
+		log.Panicln(err)
+	}
+	// visual selection (line-wise and block-wise)
+	for _, key := range []interface{}{'v', 'V'} {
+		if err := setKeybinding(g, ws.name, key, gocui.ModNone, "start line-wise selection", startSelection(false)); err != nil {
+			log.Panicln(err)
+		}
+	}
+	if err := setKeybinding(g, ws.name, gocui.KeyCtrlV, gocui.ModNone, "start block-wise selection", startSelection(true)); err != nil {
+		log.Panicln(err)
+	}
+	// zoom (full screen) toggle
+	if err := setKeybinding(g, ws.name, 'z', gocui.ModNone, "zoom view to full screen (toggle)", toggleZoom); err != nil {
+		log.Panicln(err)
+	}
+}
+
+// Position returns position in the stack of widgets
+func (ws *WidgetStack) Position() int {
+	return ws.pos
+}
+
+// Print append a text to the widget content.
+// Printed line or word will be highlighted if such word exist in `highlight` map in WidgetStack.
+// ANSI colors from the output are preserved (or removed with `strip-ansi`).
+// Changes from previous refresh are marked when change detection is turned on (`watch-diff`).
+func (ws *WidgetStack) Print(str string) {
+	if ws.gview != nil {
+		ws.gview.Autoscroll = true
+		if len(ws.highlight) > 0 || ws.diff != nil || ws.stripAnsi || strings.Contains(str, "\x1b") {
+			// remove last new line
+			lines := strings.Split(strings.TrimSuffix(str, "\n"), "\n")
+			for _, line := range lines {
+				line = ws.markLine(line)
+				ws.body += line + "\n"
+				fmt.Fprintln(ws.gview, line)
+			}
+		} else {
+			// write full text if no highlight map and no escape sequences
+			ws.body += str
+			fmt.Fprint(ws.gview, str)
+		}
+		markActivity(ws, false)
+	}
+}
+
+// Error append an error text to the widget content
+func (ws *WidgetStack) Error(err error) {
+	ws.Widget.Error(err)
+	markActivity(ws, true)
+}
+
+// reprint replace content of current refresh with the text (keeps scroll position)
+func (ws *WidgetStack) reprint(str string) {
+	if ws.gview == nil {
+		return
+	}
+	ox, oy := ws.gview.Origin()
+	ws.gview.Clear()
+	ws.body = ""
+	ws.sgr = sgrState{}
+	if ws.diff != nil {
+		ws.diff.restart()
+	}
+	if len(str) > 0 {
+		ws.Print(str)
+	}
+	ws.gview.Autoscroll = false
+	ws.gview.SetOrigin(ox, oy)
+}
+
+// SetRender setup rendering of the output (text, table or graph) from view configuration
+func (ws *WidgetStack) SetRender(v View) error {
+	if ws.render != nil {
+		// remove rendering views (like table header)
+		if r, ok := ws.render.(*WidgetTable); ok {
+			r.Enabled = false
+			if gui != nil {
+				r.Layout(gui)
+			}
+		}
+	}
+	switch v.Render {
+	case "", "text":
+		ws.render = nil
+	case "table":
+		ws.render = NewWidgetTable(ws, v.Table)
+	case "graph":
+		wg, err := NewWidgetGraph(ws, v.Graph)
+		if err != nil {
+			ws.render = nil
+			return err
+		}
+		ws.render = wg
+	default:
+		return fmt.Errorf("render %v not supported", v.Render)
+	}
+	return nil
+}
+
+// Clear clears the widget content, resets ANSI style and starts new refresh for change detection
+func (ws *WidgetStack) Clear() {
+	ws.prevBody = ws.body
+	ws.Widget.Clear()
+	ws.sgr = sgrState{}
+	if ws.diff != nil {
+		ws.diff.next()
+	}
+}
+
+// markLine colors highlighted words or line and changes from previous refresh.
+// Highlight and changes are searched in visible text, so ANSI colors from the output are preserved.
+func (ws *WidgetStack) markLine(line string) string {
+	at := parseAnsi(line, &ws.sgr)
+	if ws.stripAnsi {
+		at.stripStyles()
+	}
+	text := at.String()
+	runes := at.runes
+	hi := make([]bool, len(runes))
+	for sub, hiLine := range ws.highlight {
+		if len(sub) == 0 || !strings.Contains(text, sub) {
+			continue
+		}
+		if hiLine {
+			// highlight full line
+			for i := range hi {
+				hi[i] = true
+			}
+			break
+		}
+		// highlight word only
+		rsub := []rune(sub)
+		for i := 0; i+len(rsub) <= len(runes); i++ {
+			if string(runes[i:i+len(rsub)]) == sub {
+				for j := i; j < i+len(rsub); j++ {
+					hi[j] = true
+				}
+				i += len(rsub) - 1
+			}
+		}
+		break
+	}
+
+	var changed []bool
+	isNew, faded := false, false
+	if ws.diff != nil {
+		changed, isNew, faded = ws.diff.mark(text)
+	}
+
+	hiColor := cHighlightStr.Sequence(false)
+	for i := range at.styles {
+		if hi[i] {
+			at.styles[i].fg = hiColor
+		}
+		switch {
+		case isNew:
+			at.styles[i].attrs[1] = true
+			at.styles[i].attrs[7] = true
+		case changed != nil && changed[i]:
+			at.styles[i].attrs[7] = true
+		case faded:
+			at.styles[i].attrs[4] = true
+		}
+	}
+	return at.render()
+}
+
+// SetupFun set function to run in interval in this widget
+func (ws *WidgetStack) SetupFun(cmd string) {
+	if len(cmd) == 0 {
+		return
+	}
+
+	ws.funStr = cmd
+	var wout Widgeter = ws
+	if ws.render != nil {
+		wout = ws.render
+	}
+	realcmd := strings.TrimSpace(cmd)
+	if lexer, fcmd, ok := parseFancy(realcmd); ok {
+		realcmd = fcmd
+		wout = NewWidgetPipe(wout, lexer, realcmd)
+	}
+	// alert rules get the output without highlighting
+	wout = &alertWidget{Widgeter: wout, ws: ws}
+
+	opts := ws.job
+	if strings.HasPrefix(realcmd, "remote") {
+		ws.Fun = func() error {
+			return cmdRemote(wout, strings.TrimPrefix(realcmd, "remote"), opts)
+		}
+	} else {
+		ws.Fun = func() error {
+			return cmdShellJob(wout, realcmd, opts)
+		}
+	}
+	if !ws.paused {
+		ws.StartFun()
+	}
+}
+
+// SetRefresh setup refresh interval and schedule from view configuration
+func (ws *WidgetStack) SetRefresh(v View) error {
+	ws.refresh = defaultRefresh()
+	ws.schedule = nil
+	if v.Refresh != "" {
+		d, err := parseRefresh(v.Refresh)
+		if err != nil {
+			return err
+		}
+		ws.refresh = d
+	}
+	if v.Schedule != "" {
+		cs, err := parseSchedule(v.Schedule)
+		if err != nil {
+			return err
+		}
+		ws.schedule = cs
+	}
+	return nil
+}
+
+// SetJob setup options of the job (directory, environment, shell and timeout) from view configuration.
+// Job has to be set again to use them.
+func (ws *WidgetStack) SetJob(v View) error {
+	opts, err := newJobOptions(v)
+	if err != nil {
+		return err
+	}
+	ws.job = opts
+	return nil
+}
+
+// SetPaused stops the job of the view (paused) or starts it again.
+// View configuration is updated, so it's saved by `savecfg`.
+func (ws *WidgetStack) SetPaused(paused bool) {
+	if ws.Fun == nil {
+		return
+	}
+	ws.markPaused(paused)
+	if paused {
+		ws.StopFun()
+	} else {
+		ws.StartFun()
+	}
+}
+
+// markPaused sets paused state of the view (in configuration too) without starting or stopping the job
+func (ws *WidgetStack) markPaused(paused bool) {
+	ws.paused = paused
+	if vmap, ok := config.Views[ws.name]; ok {
+		vmap.Paused = paused
+		config.Views[ws.name] = vmap
+	}
+}
+
+// GetFunString return command running in this widget
+func (ws *WidgetStack) GetFunString() string {
+	return ws.funStr
+}
+
+// StartFun starts a function for the view to update it's content.
+// Function has to return string which is used for update
+func (ws *WidgetStack) StartFun() {
+	// check if function is set
+	if ws.Fun == nil {
+		return
+	}
+	// stop previous run (every run has its own stop channel)
+	ws.StopFun()
+	stop := make(chan bool, 1)
+	ws.stopFun = stop
+
+	// setup goroutine
+	go func() {
+		// setup action function (function can be changed or removed while running)
+		fun := ws.Fun
+		var start time.Time
+		action := func() error {
+			start = time.Now()
+			if err := fun(); err != nil {
+				appendErrorMsgToView(ws, err)
+				ws.recordRun(start, err)
+				return err
+			}
+			return nil
+		}
+		// run only when the view is in the schedule
+		scheduled := func() bool {
+			return ws.schedule == nil || ws.schedule.matches(time.Now())
+		}
+		// run it for the first time
+		running := scheduled() && action() == nil
+
+		for {
+			// To make it possible to kill the Fun, we need to listen to 2 different channels
+			// one for stopFun and one for timeout, which would start the action again
+			sleepTime := make(chan struct{})
+			// sleeping goroutine
+			go func() {
+				<-time.After(ws.refresh)
+				close(sleepTime)
+			}()
+			if running {
+				conn := ws.conn
+				select {
+				case <-stop:
+					ws.Disconnect() // disconnect content channel
+					// w.conn.Stop()
+					return
+				case <-conn.IsEnd():
+					ws.recordRun(start, conn.failure)
+				}
+			}
+			select {
+			case <-stop:
+				ws.Disconnect()
+				return
+			case <-sleepTime:
+			}
+			running = scheduled() && action() == nil
+		}
+	}()
+}
+
+// StopFun stops function running to update widget
+func (ws *WidgetStack) StopFun() {
+	if ws.Fun == nil {
+		return
+	}
+	select {
+	case ws.stopFun <- true:
+	default:
+		// channel is full, screw another write...
+	}
+}
+
+// Change refresh rate of the widget content (Fun stuff)
+func changeRefresh(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	if ws := getWidgetStack(v.Name()); ws != nil {
+		// next interval of 2s, 5s, 10s (from any other interval it starts again with 2s)
+		next := 2 * time.Second
+		for _, d := range []time.Duration{2 * time.Second, 5 * time.Second, 10 * time.Second} {
+			if d > ws.refresh {
+				next = d
+				break
+			}
+		}
+		ws.refresh = next
+		if vmap, ok := config.Views[ws.name]; ok {
+			vmap.Refresh = next.String()
+			config.Views[ws.name] = vmap
+		}
+		if !ws.paused {
+			ws.StartFun()
+		}
+		// add notification pop-up
+		if wf, err := addSimplePopupWidget("refresh-popup", cPopup, ws.x0+1, ws.y1-4, ws.x1-2, 3,
+			fmt.Sprintf("refresh interval changed to %v", ws.refresh)); err == nil {
+			// with CtrlR keybind to refresh THIS view (widget, not widget-floaty)
+			deleteKeybinding(g, wf.name, gocui.KeyCtrlR, gocui.ModNone) // don't care about errors (just to not duplicate it)
+			if err := setKeybinding(g, wf.name, gocui.KeyCtrlR, gocui.ModNone, "change refresh interval of the view again",
+				func(g *gocui.Gui, v *gocui.View) error {
+					nv, err := g.View(ws.GetName())
+					if err != nil {
+						return err
+					}
+					return changeRefresh(g, nv)
+				}); err != nil {
+				log.Panicln(err)
+			}
+			// with KeyTab keybind to change to NEXT view directly (widget, not widget-floaty)
+			deleteKeybinding(g, wf.name, gocui.KeyTab, gocui.ModNone) // don't care about errors (just to not duplicate it)
+			if err := setKeybinding(g, wf.name, gocui.KeyTab, gocui.ModNone, "select next view",
+				func(g *gocui.Gui, v *gocui.View) error {
+					nv, err := g.View(ws.GetName())
+					if err != nil {
+						return err
+					}
+					// close this floaty
+					closeFloatyWidget(g, v)
+					// change ;)
+					changeView(g, nv)
+					return nil
+				}); err != nil {
+				log.Panicln(err)
+			}
+
+		}
+	}
+	return nil
+}
@@ -0,0 +1,489 @@
+package zterm
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/mattn/go-runewidth"
+)
+
+// TableConfig configuration of the table rendering for a view (`render: table`)
+type TableConfig struct {
+	Format     string               `mapstructure:"format,omitempty" yaml:"format,omitempty"` // auto, whitespace, csv or json
+	Sort       string               `mapstructure:"sort,omitempty" yaml:"sort,omitempty"`     // column key, with `-` prefix for descending order
+	Hide       []string             `mapstructure:"hide,omitempty" yaml:"hide,omitempty"`
+	Thresholds map[string]Threshold `mapstructure:"thresholds,omitempty" yaml:"thresholds,omitempty"`
+}
+
+// Threshold colors column value when it reaches warning or critical value (unset value isn't checked)
+type Threshold struct {
+	Warn *float64 `mapstructure:"warn,omitempty" yaml:"warn,omitempty"`
+	Crit *float64 `mapstructure:"crit,omitempty" yaml:"crit,omitempty"`
+}
+
+// WidgetTable structure for rendering columnar output into the WidgetStack
+type WidgetTable struct {
+	Widget
+	stack     *WidgetStack
+	conf      TableConfig
+	lines     []string   // output lines of current run (without ANSI), to parse them again with other format
+	partial   string     // last line of the output which isn't complete yet
+	format    string     // format of current run (empty until it's detected)
+	commas    int        // number of commas in csv header (line with less commas isn't csv)
+	jsonBuf   string     // json output which isn't decoded yet
+	jsonArray bool       // json rows are inside of array
+	header    []string   // column keys
+	rows      [][]string // parsed rows
+	dirty     bool       // rows changed, table is rendered again in Layout (once per screen refresh)
+}
+
+var numberRe = regexp.MustCompile(`^[-+]?[0-9]*\.?[0-9]+`)
+
+// NewWidgetTable creates a table widget which parse output sent to it and display it as table in the stack widget.
+func NewWidgetTable(ws *WidgetStack, conf TableConfig) *WidgetTable {
+	return &WidgetTable{Widget: Widget{name: ws.name + "-header", Enabled: true}, stack: ws, conf: conf}
+}
+
+// Layout setup for table header (fixed on top of the stack widget)
+func (wt *WidgetTable) Layout(g *gocui.Gui) error {
+	ws := wt.stack
+	if wt.Enabled && wt.dirty && ws.gview != nil {
+		// output can come in many parts, table is displayed only once for all of them
+		wt.dirty = false
+		wt.sort()
+		ws.reprint(wt.render())
+	}
+	if !wt.Enabled || ws.gview == nil || !ws.gview.Visible || len(wt.header) == 0 || ws.y1-ws.y0 < 3 {
+		g.DeleteView(wt.name) // if doesn't exist, don't care
+		wt.gview = nil
+		return nil
+	}
+	// header is placed inside the frame of stack widget (over the first line)
+	wt.x0, wt.y0, wt.x1, wt.y1 = ws.x0, ws.y0, ws.x1, ws.y0+2
+	v, err := g.SetView(wt.name, wt.x0, wt.y0, wt.x1, wt.y1, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) {
+			return fmt.Errorf("view %v: %v", wt.name, err)
+		}
+	}
+	wt.gview = v
+	v.Frame = false
+	v.Wrap = false
+	v.Clear()
+	fmt.Fprint(v, wt.renderHeader())
+	// follow horizontal scrolling of the table
+	ox, _ := ws.gview.Origin()
+	v.SetOrigin(ox, 0)
+	g.SetViewOnTop(wt.name)
+	return nil
+}
+
+// Clear starts new output for the table
+func (wt *WidgetTable) Clear() {
+	wt.lines = nil
+	wt.partial = ""
+	wt.reset("")
+	wt.dirty = false
+	wt.stack.Clear()
+}
+
+// Print append a text to the table, only new lines are parsed (table is displayed in Layout)
+func (wt *WidgetTable) Print(str string) {
+	text := wt.partial + str
+	end := strings.LastIndex(text, "\n")
+	wt.partial = text[end+1:]
+	if end < 0 {
+		return
+	}
+	lines := strings.Split(text[:end], "\n")
+	for i, line := range lines {
+		lines[i] = parseAnsi(line, nil).String()
+	}
+	wt.lines = append(wt.lines, lines...)
+	wt.parse(lines)
+	wt.dirty = true
+}
+
+// Update table configuration and display the table again
+func (wt *WidgetTable) Update(conf TableConfig) {
+	wt.conf = conf
+	if len(wt.lines) > 0 {
+		wt.reset("")
+		wt.parse(wt.lines)
+		wt.dirty = true
+	}
+}
+
+// Connect content producing channel to the stack widget
+func (wt *WidgetTable) Connect(conn *RecvConn) {
+	wt.stack.Connect(conn)
+}
+
+// Disconnect content producing channel from the stack widget
+func (wt *WidgetTable) Disconnect() {
+	wt.stack.Disconnect()
+}
+
+// Error append an error text to the stack widget
+func (wt *WidgetTable) Error(err error) {
+	wt.stack.Error(err)
+}
+
+// reset removes parsed rows, so the output can be parsed again (in the format, empty to detect it)
+func (wt *WidgetTable) reset(format string) {
+	wt.format = format
+	wt.commas = 0
+	wt.jsonBuf = ""
+	wt.jsonArray = false
+	wt.header, wt.rows = nil, nil
+}
+
+// parse new lines of the output into header and rows
+func (wt *WidgetTable) parse(lines []string) {
+	if wt.format == "" {
+		if wt.conf.Format != "" && wt.conf.Format != "auto" {
+			wt.format = wt.conf.Format
+		} else {
+			// detect from the first line with text
+			for _, line := range lines {
+				if strings.TrimSpace(line) != "" {
+					wt.format = detectTableFormat(line)
+					break
+				}
+			}
+			if wt.format == "" {
+				return
+			}
+		}
+	}
+	var err error
+	switch wt.format {
+	case "json":
+		wt.jsonBuf += strings.Join(lines, "\n") + "\n"
+		err = wt.parseJSON()
+	case "csv":
+		err = wt.parseCSV(lines)
+	default:
+		wt.parseWhitespace(lines)
+	}
+	if err != nil {
+		// not valid, display as is
+		wt.reset("whitespace")
+		wt.parseWhitespace(wt.lines)
+	}
+}
+
+// detectTableFormat detects format of the output (json, csv or whitespace) from its first line
+func detectTableFormat(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "[") || strings.HasPrefix(line, "{") {
+		return "json"
+	}
+	if strings.Contains(line, ",") {
+		return "csv"
+	}
+	return "whitespace"
+}
+
+// parseWhitespace splits lines by whitespaces, the last column contains rest of the line (like command in `ps`)
+func (wt *WidgetTable) parseWhitespace(lines []string) {
+	for _, line := range lines {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		if wt.header == nil {
+			wt.header = strings.Fields(line)
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > len(wt.header) {
+			// join rest of the line into last column
+			rest := strings.TrimSpace(line)
+			for _, f := range fields[:len(wt.header)-1] {
+				rest = strings.TrimSpace(strings.TrimPrefix(rest, f))
+			}
+			fields = append(fields[:len(wt.header)-1], rest)
+		}
+		wt.rows = append(wt.rows, fields)
+	}
+}
+
+// parseCSV parse comma separated values, first line is header.
+// Detected csv needs to have (at least) the same number of columns on all lines as header, otherwise it's not csv.
+func (wt *WidgetTable) parseCSV(lines []string) error {
+	for _, line := range lines {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		if wt.header != nil && wt.conf.Format != "csv" && strings.Count(line, ",") < wt.commas {
+			return errors.New("csv: missing columns")
+		}
+		r := csv.NewReader(strings.NewReader(line))
+		r.TrimLeadingSpace = true
+		fields, err := r.Read()
+		if err != nil {
+			return err
+		}
+		if wt.header == nil {
+			wt.header = fields
+			wt.commas = strings.Count(line, ",")
+			continue
+		}
+		wt.rows = append(wt.rows, fields)
+	}
+	return nil
+}
+
+// parseJSON decodes complete objects from json output (array of objects or objects one after another),
+// keys of the objects are columns. Order of columns is the same as the order of keys in the objects.
+// Incomplete object stays in the buffer until rest of it is printed.
+func (wt *WidgetTable) parseJSON() error {
+	for {
+		buf := strings.TrimLeft(wt.jsonBuf, " \t\r\n")
+		switch {
+		case !wt.jsonArray && strings.HasPrefix(buf, "["):
+			wt.jsonArray = true
+			wt.jsonBuf = buf[1:]
+			continue
+		case wt.jsonArray && strings.HasPrefix(buf, ","):
+			wt.jsonBuf = buf[1:]
+			continue
+		case wt.jsonArray && strings.HasPrefix(buf, "]"):
+			wt.jsonArray = false
+			wt.jsonBuf = buf[1:]
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(buf))
+		var item json.RawMessage
+		if err := dec.Decode(&item); err == io.EOF || err == io.ErrUnexpectedEOF {
+			// wait for the rest
+			wt.jsonBuf = buf
+			return nil
+		} else if err != nil {
+			return err
+		}
+		wt.jsonBuf = buf[dec.InputOffset():]
+		if err := wt.addJSONRow(item); err != nil {
+			return err
+		}
+	}
+}
+
+// addJSONRow adds json object as a row (new keys are added as columns)
+func (wt *WidgetTable) addJSONRow(item json.RawMessage) error {
+	keys, err := jsonKeys(item)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if indexOfColumn(wt.header, k) < 0 {
+			wt.header = append(wt.header, k)
+		}
+	}
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(item, &obj); err != nil {
+		return err
+	}
+	row := make([]string, len(wt.header))
+	for i, k := range wt.header {
+		if val, ok := obj[k]; ok && val != nil {
+			switch v := val.(type) {
+			case string:
+				row[i] = v
+			case float64:
+				row[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				b, _ := json.Marshal(v)
+				row[i] = string(b)
+			}
+		}
+	}
+	wt.rows = append(wt.rows, row)
+	return nil
+}
+
+// jsonKeys returns keys of json object in the original order
+func jsonKeys(obj json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	if t, err := dec.Token(); err != nil {
+		return nil, err
+	} else if d, ok := t.(json.Delim); !ok || d != '{' {
+		return nil, errors.New("json: table row has to be an object")
+	}
+	var keys []string
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, t.(string))
+		// skip value
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// indexOfColumn returns index of the column by key (case insensitive), or -1 if not found
+func indexOfColumn(header []string, key string) int {
+	for i, h := range header {
+		if strings.EqualFold(h, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseNumber parse number from the beginning of the value (like 85% or 1.5G)
+func parseNumber(val string) (float64, bool) {
+	num := numberRe.FindString(strings.TrimSpace(val))
+	if num == "" {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	return f, err == nil
+}
+
+// sort rows by the column from configuration
+func (wt *WidgetTable) sort() {
+	key := wt.conf.Sort
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	col := indexOfColumn(wt.header, key)
+	if key == "" || col < 0 {
+		return
+	}
+	cell := func(row []string) string {
+		if col < len(row) {
+			return row[col]
+		}
+		return ""
+	}
+	sort.SliceStable(wt.rows, func(i, j int) bool {
+		a, b := cell(wt.rows[i]), cell(wt.rows[j])
+		if desc {
+			a, b = b, a
+		}
+		na, aok := parseNumber(a)
+		nb, bok := parseNumber(b)
+		if aok && bok && na != nb {
+			return na < nb
+		}
+		return a < b
+	})
+}
+
+// visibleColumns returns indexes of columns which are not hidden
+func (wt *WidgetTable) visibleColumns() (cols []int) {
+	for i, h := range wt.header {
+		hidden := false
+		for _, hide := range wt.conf.Hide {
+			if strings.EqualFold(h, hide) {
+				hidden = true
+				break
+			}
+		}
+		if !hidden {
+			cols = append(cols, i)
+		}
+	}
+	return
+}
+
+// columnWidths returns width of each column (by maximum width of the value in column)
+func (wt *WidgetTable) columnWidths() []int {
+	widths := make([]int, len(wt.header))
+	for i, h := range wt.header {
+		widths[i] = runewidth.StringWidth(h)
+	}
+	for _, row := range wt.rows {
+		for i, c := range row {
+			if i < len(widths) && runewidth.StringWidth(c) > widths[i] {
+				widths[i] = runewidth.StringWidth(c)
+			}
+		}
+	}
+	return widths
+}
+
+// renderHeader returns header line of the table
+func (wt *WidgetTable) renderHeader() string {
+	widths := wt.columnWidths()
+	sortKey := strings.TrimPrefix(wt.conf.Sort, "-")
+	var sb strings.Builder
+	for _, c := range wt.visibleColumns() {
+		title := wt.header[c]
+		if sortKey != "" && strings.EqualFold(title, sortKey) {
+			if strings.HasPrefix(wt.conf.Sort, "-") {
+				title += "▼"
+			} else {
+				title += "▲"
+			}
+		}
+		sb.WriteString(runewidth.FillRight(title, widths[c]+1) + " ")
+	}
+	return "\x1b[1m\x1b[4m" + strings.TrimRight(sb.String(), " ") + "\x1b[0m"
+}
+
+// render returns the whole table (header and rows) as text
+func (wt *WidgetTable) render() string {
+	if len(wt.header) == 0 {
+		return ""
+	}
+	widths := wt.columnWidths()
+	cols := wt.visibleColumns()
+	var sb strings.Builder
+	sb.WriteString(wt.renderHeader() + "\n")
+	for _, row := range wt.rows {
+		var line strings.Builder
+		for _, c := range cols {
+			val := ""
+			if c < len(row) {
+				val = row[c]
+			}
+			cell := runewidth.FillRight(val, widths[c]+1) + " "
+			if color := wt.thresholdColor(wt.header[c], val); color != "" {
+				cell = "\x1b[" + color + "m" + cell + "\x1b[0m"
+			}
+			line.WriteString(cell)
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+	return sb.String()
+}
+
+// thresholdColor returns SGR color parameter for value in the column, if it reached threshold
+func (wt *WidgetTable) thresholdColor(column string, val string) string {
+	for key, th := range wt.conf.Thresholds {
+		if !strings.EqualFold(key, column) {
+			continue
+		}
+		num, ok := parseNumber(val)
+		if !ok {
+			return ""
+		}
+		// lower critical value than warning means lower value is worse
+		reversed := th.Crit != nil && th.Warn != nil && *th.Crit < *th.Warn
+		reached := func(limit *float64) bool {
+			return limit != nil && ((!reversed && num >= *limit) || (reversed && num <= *limit))
+		}
+		switch {
+		case reached(th.Crit):
+			return cErrorStr.Sequence(false)
+		case reached(th.Warn):
+			return cWarningStr.Sequence(false)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+package zterm
+
+import (
+	"reflect"
+	"testing"
+)
+
+// parseTable parses lines of the output like WidgetTable.Print does
+func parseTable(conf TableConfig, lines ...string) *WidgetTable {
+	wt := &WidgetTable{conf: conf}
+	wt.lines = lines
+	wt.parse(lines)
+	return wt
+}
+
+func TestDetectTableFormat(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"  [{\"a\": 1}]", "json"},
+		{`{"a": 1}`, "json"},
+		{"NAME,STATUS,RC", "csv"},
+		{"USER  PID %CPU", "whitespace"},
+	}
+	for _, tt := range tests {
+		if got := detectTableFormat(tt.line); got != tt.want {
+			t.Errorf("detectTableFormat(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestTableParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		lines  []string
+		header []string
+		rows   [][]string
+	}{
+		{"whitespace", "", []string{"USER PID COMMAND", "", "root 1 /sbin/init  splash", "ibm  22 sh"},
+			[]string{"USER", "PID", "COMMAND"}, [][]string{{"root", "1", "/sbin/init  splash"}, {"ibm", "22", "sh"}}},
+		{"csv", "", []string{"NAME, STATUS,RC", `JOB1,"OUTPUT, HELD",0`},
+			[]string{"NAME", "STATUS", "RC"}, [][]string{{"JOB1", "OUTPUT, HELD", "0"}}},
+		{"csv with missing columns", "", []string{"a,b", "x y"},
+			[]string{"a,b"}, [][]string{{"x y"}}},
+		{"json array", "", []string{`[{"name": "a", "rc": 4},`, `{"name": "b", "ok": true, "rc": null}]`},
+			[]string{"name", "rc", "ok"}, [][]string{{"a", "4"}, {"b", "", "true"}}},
+		{"json objects", "json", []string{`{"b": 1.5, "a": "x"}`, `{"a": "y", "b": [1]}`},
+			[]string{"b", "a"}, [][]string{{"1.5", "x"}, {"[1]", "y"}}},
+		{"invalid json", "", []string{"[1, 2]"},
+			[]string{"[1,", "2]"}, nil},
+	}
+	for _, tt := range tests {
+		wt := parseTable(TableConfig{Format: tt.format}, tt.lines...)
+		if !reflect.DeepEqual(wt.header, tt.header) || !reflect.DeepEqual(wt.rows, tt.rows) {
+			t.Errorf("%v: parse = %q %q, want %q %q", tt.name, wt.header, wt.rows, tt.header, tt.rows)
+		}
+	}
+}
+
+func TestTableParseIncompleteJSON(t *testing.T) {
+	wt := parseTable(TableConfig{}, `[{"name": "a",`)
+	if len(wt.rows) != 0 {
+		t.Fatalf("incomplete object parsed: %q", wt.rows)
+	}
+	wt.parse([]string{`"rc": 0}]`})
+	if want := [][]string{{"a", "0"}}; !reflect.DeepEqual(wt.rows, want) {
+		t.Errorf("rows = %q, want %q", wt.rows, want)
+	}
+}
+
+func TestTableSort(t *testing.T) {
+	lines := []string{"NAME SIZE", "b 10G", "a 9G", "c 10.5G", "d -"}
+	wt := parseTable(TableConfig{Sort: "-size"}, lines...)
+	wt.sort()
+	var names []string
+	for _, row := range wt.rows {
+		names = append(names, row[0])
+	}
+	if want := []string{"c", "b", "a", "d"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sort = %q, want %q", names, want)
+	}
+}
+
+func TestTableRender(t *testing.T) {
+	wt := parseTable(TableConfig{Hide: []string{"id"}, Sort: "name"}, "ID NAME SIZE", "1 日本 10", "2 abc 9")
+	want := "\x1b[1m\x1b[4mNAME▲ SIZE\x1b[0m\n" +
+		"日本  10\n" +
+		"abc   9\n"
+	if got := wt.render(); got != want {
+		t.Errorf("render = %q, want %q", got, want)
+	}
+}
+
+func TestThresholdColor(t *testing.T) {
+	num := func(f float64) *float64 { return &f }
+	warn, crit := cWarningStr.Sequence(false), cErrorStr.Sequence(false)
+	tests := []struct {
+		th   Threshold
+		val  string
+		want string
+	}{
+		{Threshold{Warn: num(50), Crit: num(90)}, "10", ""},
+		{Threshold{Warn: num(50), Crit: num(90)}, "50.5", warn},
+		{Threshold{Warn: num(50), Crit: num(90)}, "95%", crit},
+		{Threshold{Warn: num(50), Crit: num(90)}, "n/a", ""},
+		// lower value is worse
+		{Threshold{Warn: num(20), Crit: num(5)}, "30", ""},
+		{Threshold{Warn: num(20), Crit: num(5)}, "10G", warn},
+		{Threshold{Warn: num(20), Crit: num(5)}, "0", crit},
+		// unset threshold isn't checked
+		{Threshold{Warn: num(80)}, "0", ""},
+		{Threshold{Warn: num(80)}, "70", ""},
+		{Threshold{Warn: num(80)}, "85", warn},
+		{Threshold{Crit: num(90)}, "0", ""},
+		{Threshold{Crit: num(90)}, "90", crit},
+		{Threshold{Warn: num(0)}, "0", warn},
+		{Threshold{}, "100", ""},
+	}
+	for i, tt := range tests {
+		wt := &WidgetTable{conf: TableConfig{Thresholds: map[string]Threshold{"%cpu": tt.th}}}
+		if got := wt.thresholdColor("%CPU", tt.val); got != tt.want {
+			t.Errorf("%d: thresholdColor(%q) = %q, want %q", i, tt.val, got, tt.want)
+		}
+		if got := wt.thresholdColor("%MEM", tt.val); got != "" {
+			t.Errorf("thresholdColor(%q) of other column = %q, want none", tt.val, got)
+		}
+	}
+}
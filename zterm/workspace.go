@@ -0,0 +1,227 @@
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+// Workspace configuration (named set of views with its own layout)
+type Workspace struct {
+	Inactive string          `mapstructure:"inactive,omitempty" yaml:"inactive,omitempty"` // run (default) or pause jobs when workspace is not displayed
+	Views    map[string]View `mapstructure:"views,omitempty" yaml:"views,omitempty"`
+	Layout   *LayoutNode     `mapstructure:"layout,omitempty" yaml:"layout,omitempty"`
+}
+
+// workspace is a runtime state of the workspace
+type workspace struct {
+	name     string
+	inactive string
+	views    map[string]View
+	layout   *LayoutNode
+	current  string // selected view
+	activity bool   // output changed while workspace was not displayed
+}
+
+const (
+	mainWorkspace = "main" // workspace with views from `views` and `layout` config
+	tabsView      = "workspace-tabs"
+)
+
+var (
+	workspaces    []*workspace
+	currWorkspace *workspace
+)
+
+// setupWorkspaces prepares workspaces from configuration.
+// Main workspace (views from `views` config) is the first one, others are sorted by name.
+func setupWorkspaces() {
+	mainWsp := &workspace{name: mainWorkspace, views: config.Views, layout: config.Layout}
+	names := []string{}
+	for name, wc := range config.Workspaces {
+		if name == mainWorkspace {
+			mainWsp.inactive = wc.Inactive
+			for vname, v := range wc.Views {
+				mainWsp.views[vname] = v
+			}
+			if mainWsp.layout == nil {
+				mainWsp.layout = wc.Layout
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	workspaces = []*workspace{}
+	if len(mainWsp.views) > 0 || len(names) == 0 {
+		workspaces = append(workspaces, mainWsp)
+	}
+	for _, name := range names {
+		wc := config.Workspaces[name]
+		views := wc.Views
+		if views == nil {
+			views = map[string]View{}
+		}
+		workspaces = append(workspaces, &workspace{name: name, inactive: wc.Inactive, views: views, layout: wc.Layout})
+	}
+	currWorkspace = workspaces[0]
+	config.Views = currWorkspace.views
+}
+
+// getWorkspace returns workspace by name
+func getWorkspace(name string) *workspace {
+	for _, wsp := range workspaces {
+		if wsp.name == name {
+			return wsp
+		}
+	}
+	return nil
+}
+
+// stacks returns widget stacks of the workspace
+func (wsp *workspace) stacks() (wlist []*WidgetStack) {
+	for _, w := range widgets {
+		if ws, ok := w.(*WidgetStack); ok && ws.workspace == wsp.name {
+			wlist = append(wlist, ws)
+		}
+	}
+	return
+}
+
+// switchWorkspace displays views of the workspace (created if doesn't exist).
+// Jobs of the previous workspace are paused if it's set so.
+func switchWorkspace(g *gocui.Gui, name string) error {
+	wsp := getWorkspace(name)
+	if wsp == nil {
+		wsp = &workspace{name: name, views: map[string]View{}, layout: &LayoutNode{Split: "rows"}}
+		workspaces = append(workspaces, wsp)
+	}
+	if wsp == currWorkspace {
+		return nil
+	}
+
+	// save state of current workspace
+	old := currWorkspace
+	old.views = config.Views
+	old.layout = layoutRoot
+	if v := g.CurrentView(); v != nil && getWidgetStack(v.Name()) != nil {
+		old.current = v.Name()
+	}
+	if wc := getConsoleWidget(); wc != nil && wc.lastView != "" {
+		old.current = wc.lastView
+	}
+	if old.inactive == "pause" {
+		for _, ws := range old.stacks() {
+			ws.StopFun()
+		}
+	}
+
+	// load new workspace (zoom is not kept)
+	zoomedView = ""
+	currWorkspace = wsp
+	wsp.activity = false
+	config.Views = wsp.views
+	layoutRoot = wsp.layout
+	layoutRenumber()
+	arrangeLayout(g)
+	if wc := getConsoleWidget(); wc != nil && config.History.Scope == "workspace" {
+		wc.loadHistory()
+	}
+	if wsp.inactive == "pause" {
+		for _, ws := range wsp.stacks() {
+			if !ws.paused {
+				ws.StartFun()
+			}
+		}
+	}
+
+	// select view (console keeps focus, so it's selected after console is closed)
+	focus := wsp.current
+	if getWidgetStack(focus) == nil {
+		focus = ""
+		if wslist := getSortedWidgetStack(); len(wslist) > 0 {
+			focus = wslist[0].GetName()
+		}
+	}
+	if wc := getConsoleWidget(); wc != nil && !wc.IsHidden() {
+		wc.lastView = focus
+	} else if focus != "" {
+		g.SetCurrentView(focus)
+	}
+	return nil
+}
+
+// markActivity marks workspace of the widget stack as active when its output changed (or failed) while it's not displayed
+func markActivity(ws *WidgetStack, failed bool) {
+	if currWorkspace == nil || ws.workspace == currWorkspace.name {
+		return
+	}
+	if wsp := getWorkspace(ws.workspace); wsp != nil && (failed || !strings.HasPrefix(ws.prevBody, ws.body)) {
+		wsp.activity = true
+	}
+}
+
+// layoutTabs displays tab bar with workspaces on top of the screen (only if there are more workspaces)
+func layoutTabs(g *gocui.Gui) error {
+	if len(workspaces) < 2 {
+		g.DeleteView(tabsView) // if doesn't exist, don't care
+		return nil
+	}
+	maxX, _ := g.Size()
+	v, err := g.SetView(tabsView, -1, -1, maxX, 1, 0)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return fmt.Errorf("view %v: %v", tabsView, err)
+	}
+	v.Frame = false
+	v.Clear()
+	for _, wsp := range workspaces {
+		label := tabLabel(wsp)
+		if wsp.activity {
+			label = strings.Replace(label, "*", colorText("*", cHighlightStr), 1)
+		}
+		if wsp == currWorkspace {
+			fmt.Fprint(v, colorText(label, cFrameSelStr))
+		} else {
+			fmt.Fprint(v, colorText(label, cFrameStr))
+		}
+	}
+	return nil
+}
+
+// tabLabel returns label of the workspace in tab bar (with activity marker)
+func tabLabel(wsp *workspace) string {
+	idx := 0
+	for i, w := range workspaces {
+		if w == wsp {
+			idx = i + 1
+		}
+	}
+	label := fmt.Sprintf("| %d:%v |", idx, wsp.name)
+	if wsp == currWorkspace {
+		label = fmt.Sprintf("[ %d:%v ]", idx, wsp.name)
+	}
+	if wsp.activity {
+		label += "*"
+	}
+	return label + " "
+}
+
+// keybindsWorkspace sets Alt+1..9 to switch workspaces
+func keybindsWorkspace(g *gocui.Gui) {
+	for i := 1; i <= 9; i++ {
+		idx := i - 1
+		if err := setKeybinding(g, "", rune('0'+i), gocui.ModAlt, "switch to workspace by number", func(g *gocui.Gui, v *gocui.View) error {
+			if idx >= len(workspaces) {
+				return nil
+			}
+			return switchWorkspace(g, workspaces[idx].name)
+		}); err != nil {
+			log.Panicln(err)
+		}
+	}
+}
@@ -1,248 +1,354 @@
-package zterm
-
-import (
-	"errors"
-	"fmt"
-	"log"
-	"os"
-	"sort"
-
-	"github.com/awesome-gocui/gocui"
-	"github.com/melbahja/goph"
-	"github.com/spf13/viper"
-)
-
-// Server configuration
-type Server struct {
-	Host string
-	User string
-}
-
-// View configuration
-type View struct {
-	Position int      `mapstructure:"position"`
-	Size     int      `mapstructure:"size"`
-	Job      string   `mapstructure:"job,omitempty"`
-	HiLine   []string `mapstructure:"hiline,omitempty"`
-	HiWord   []string `mapstructure:"hiword,omitempty"`
-}
-
-// Config type defining configuration
-type Config struct {
-	Server `mapstructure:"server"`
-	Theme  `mapstructure:"theme"`
-	Views  map[string]View `mapstructure:"views"`
-}
-
-var (
-	// default config with empty View map (so we don't have to do make)
-	config = Config{
-		Server{},
-		Theme{},
-		map[string]View{},
-	}
-
-	// widget/view parameters
-	viewMaxSize  = 0
-	viewLastPos  = 0
-	viewFirstPos = -1
-	widgets      []Widgeter
-	gui          *gocui.Gui
-
-	// ssh connection
-	sshConn *goph.Client
-
-	// ErrSuspend error cause gocui environment to suspend
-	ErrSuspend = errors.New("suspend")
-
-	suspendChan chan struct{}
-	resumeChan  chan struct{}
-)
-
-// Main function of zterm package
-//
-// - setup GUI for TUI (terminal user interface)
-//
-// - set layout
-//
-// - set keybindings
-//
-// - run GUI.MainLoop
-func Main(remote bool) {
-	var err error
-
-	// load config file (or arguments)
-	viper.Unmarshal(&config)
-
-	// load theme from config
-	LoadTheme()
-
-	if remote {
-		// setup ssh configuration
-		sshConn, err = sshNewConnect(config.Server.Host, 22, config.Server.User)
-	}
-
-	// For Windows 7 or other non-compatible stuff
-	// This is how in `tcell/v2` we can bypass scrolling problem
-	if config.ColorSpace == "basic" {
-		os.Setenv("TCELL_TRUECOLOR", "disable")
-	} else if config.ColorSpace == "truecolor" {
-		os.Setenv("COLORTERM", "truecolor")
-	}
-	// setup UI
-	g, err := gocui.NewGui(gocui.OutputTrue, true)
-	if err != nil {
-		log.Panicln(err)
-	}
-	defer g.Close()
-	gui = g // save pointer for use outside
-	g.FgColor = cFgColor
-	g.BgColor = cBgColor
-
-	// prepare widgets
-	widgets = setupManagers()
-	g.SetManagerFunc(handleLayouts)
-
-	hasWidgets := false
-	// set keybinds (after layout manager)
-	for _, w := range widgets {
-		w.Keybinds(g)
-		if w.GetName() != cmdView {
-			hasWidgets = true
-		}
-	}
-	keybindsGlobal(g)
-
-	// if no widget stack, show help
-	if !hasWidgets {
-		// this needs to be run at the end, because it handles all keybinds and layouts and stuff
-		PopupHelpWidget()
-	}
-
-	// main loop running
-	if err := g.MainLoop(); err != nil && !errors.Is(err, gocui.ErrQuit) {
-		g.Cursor = true
-		log.Panicln(err)
-	}
-	g.Cursor = true
-}
-
-// setupManagers prepare list of widgets where each of them manage its own layout and data
-func setupManagers() []Widgeter {
-	managers := []Widgeter{}
-
-	// add configured views
-	for vname, v := range config.Views {
-		viewMaxSize += v.Size // setup view maximum size
-		// setup first view position (if nothing set before)
-		if viewFirstPos < 0 {
-			viewFirstPos = v.Position
-		}
-		widget := NewWidgetStack(vname, v.Position, v.Size, fmt.Sprintf("Loading %v...\n", vname))
-		// check if last position
-		if viewLastPos < v.Position {
-			viewLastPos = v.Position
-		}
-		// check if first position
-		if viewFirstPos > v.Position {
-			viewFirstPos = v.Position
-		}
-		// setup job for view ;)
-		widget.SetupFun(v.Job)
-		// setup highlight
-		widget.highlight = make(map[string]bool)
-		for _, hi := range v.HiWord {
-			widget.highlight[hi] = false
-		}
-		for _, hi := range v.HiLine {
-			widget.highlight[hi] = true
-		}
-
-		// add to manager list
-		managers = append(managers, widget)
-	}
-	sortWidgetManager(managers)
-
-	// add console widget
-	managers = append(managers, NewWidgetConsole())
-	return managers
-}
-
-// Handle layouts of all the widgets (called by managerFunc)
-func handleLayouts(g *gocui.Gui) error {
-	for _, w := range widgets {
-		if err := w.Layout(g); err != nil {
-			return err
-		}
-	}
-	// handle cursor visibility (for editable only)
-	if v := g.CurrentView(); v != nil {
-		if v.Editable {
-			g.Cursor = true
-		} else {
-			g.Cursor = false
-		}
-	}
-	return nil
-}
-
-func getWidgetManager(name string) Widgeter {
-	for _, w := range widgets {
-		if w.GetName() == name {
-			return w
-		}
-	}
-	return nil
-}
-
-// Sort WidgetStacks in Widgeter list
-func sortWidgetManager(managers []Widgeter) {
-	sort.Slice(managers, func(i, j int) bool {
-		return managers[i].Position() < managers[j].Position()
-	})
-}
-
-func getWidgetView(name string) *gocui.View {
-	for _, w := range widgets {
-		if w.GetName() == name {
-			return w.GetView()
-		}
-	}
-	return nil
-}
-
-func getConsoleWidget() *WidgetConsole {
-	for _, w := range widgets {
-		if w.GetName() == cmdView {
-			if wc, ok := w.(*WidgetConsole); ok {
-				return wc
-			}
-			return nil
-		}
-	}
-	return nil
-}
-
-func getWidgetStack(name string) *WidgetStack {
-	for _, w := range widgets {
-		if w.GetName() == name {
-			if ws, ok := w.(*WidgetStack); ok {
-				return ws
-			}
-			return nil
-		}
-	}
-	return nil
-}
-
-func getSortedWidgetStack() (wlist []*WidgetStack) {
-	for _, w := range widgets {
-		if ws, ok := w.(*WidgetStack); ok {
-			wlist = append(wlist, ws)
-		}
-	}
-	sort.Slice(wlist, func(i, j int) bool {
-		return wlist[i].pos < wlist[j].pos
-	})
-	return
-}
+package zterm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/melbahja/goph"
+	"github.com/spf13/viper"
+)
+
+// Server configuration
+type Server struct {
+	Host    string
+	User    string
+	Refresh int // default refresh interval of views in seconds
+}
+
+// View configuration
+type View struct {
+	Position int      `mapstructure:"position"`
+	Size     int      `mapstructure:"size"`
+	Job      string   `mapstructure:"job,omitempty"`
+	HiLine   []string `mapstructure:"hiline,omitempty"`
+	HiWord   []string `mapstructure:"hiword,omitempty"`
+	// highlight changes between refreshes (like `watch -d`)
+	WatchDiff bool `mapstructure:"watch-diff,omitempty" yaml:"watch-diff,omitempty"`
+	WatchFade int  `mapstructure:"watch-fade,omitempty" yaml:"watch-fade,omitempty"`
+	// display output without ANSI colors
+	StripAnsi bool `mapstructure:"strip-ansi,omitempty" yaml:"strip-ansi,omitempty"`
+	// render output as text (default), table or graph
+	Render string      `mapstructure:"render,omitempty" yaml:"render,omitempty"`
+	Table  TableConfig `mapstructure:"table,omitempty" yaml:"table,omitempty"`
+	Graph  GraphConfig `mapstructure:"graph,omitempty" yaml:"graph,omitempty"`
+	// view is not displayed (job is still running)
+	Hidden bool `mapstructure:"hidden,omitempty" yaml:"hidden,omitempty"`
+	// refresh interval (like 30s or 1m), cron-like schedule when the view refreshes and paused view (job doesn't run)
+	Refresh  string `mapstructure:"refresh,omitempty" yaml:"refresh,omitempty"`
+	Schedule string `mapstructure:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Paused   bool   `mapstructure:"paused,omitempty" yaml:"paused,omitempty"`
+	// working directory, environment variables (NAME=value list, viper lowercases keys of maps), shell (sh, bash, zsh
+	// or none) and timeout (like 30s) of the job
+	Cwd     string   `mapstructure:"cwd,omitempty" yaml:"cwd,omitempty"`
+	Env     []string `mapstructure:"env,omitempty" yaml:"env,omitempty"`
+	Shell   string   `mapstructure:"shell,omitempty" yaml:"shell,omitempty"`
+	Timeout string   `mapstructure:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// alert rules matching new output lines
+	Alerts []AlertRule `mapstructure:"alerts,omitempty" yaml:"alerts,omitempty"`
+}
+
+// Config type defining configuration
+type Config struct {
+	Server `mapstructure:"server"`
+	Theme  `mapstructure:"theme"`
+	Views  map[string]View `mapstructure:"views"`
+	Layout *LayoutNode     `mapstructure:"layout"`
+	// other workspaces (`views` and `layout` are in main workspace)
+	Workspaces map[string]Workspace `mapstructure:"workspaces"`
+	// local clipboard command (text is on stdin), OSC 52 is used only when it's not set
+	Clipboard string `mapstructure:"clipboard"`
+	// console command history
+	History History `mapstructure:"history"`
+	// console aliases (name=command) and macros (list of commands with positional parameters $1..$9, $@)
+	Aliases map[string]string   `mapstructure:"aliases"`
+	Macros  map[string][]string `mapstructure:"macros"`
+	// mouse mode (enabled when not set), disabled mouse keeps text selection of the terminal
+	Mouse *bool `mapstructure:"mouse"`
+}
+
+var (
+	// default config with empty View map (so we don't have to do make)
+	config = Config{
+		Server{},
+		Theme{},
+		map[string]View{},
+		nil,
+		map[string]Workspace{},
+		"",
+		History{},
+		map[string]string{},
+		map[string][]string{},
+		nil,
+	}
+
+	// widget/view parameters
+	viewMaxSize  = 0
+	viewLastPos  = 0
+	viewFirstPos = -1
+	widgets      []Widgeter
+	gui          *gocui.Gui
+
+	// ssh connection
+	sshConn *goph.Client
+
+	// ErrSuspend error cause gocui environment to suspend
+	ErrSuspend = errors.New("suspend")
+
+	suspendChan chan struct{}
+	resumeChan  chan struct{}
+)
+
+// Main function of zterm package
+//
+// - setup GUI for TUI (terminal user interface)
+//
+// - set layout
+//
+// - set keybindings
+//
+// - run GUI.MainLoop
+func Main(remote bool, script string, keepGoing bool) {
+	var err error
+
+	// load config file (or arguments)
+	viper.Unmarshal(&config)
+
+	// load theme from config
+	LoadTheme()
+	// register custom lexers for fancy
+	registerLexers()
+
+	if remote {
+		// setup ssh configuration
+		sshConn, err = sshNewConnect(config.Server.Host, 22, config.Server.User)
+	}
+
+	// For Windows 7 or other non-compatible stuff
+	// This is how in `tcell/v2` we can bypass scrolling problem
+	if config.ColorSpace == "basic" {
+		os.Setenv("TCELL_TRUECOLOR", "disable")
+	} else if config.ColorSpace == "truecolor" {
+		os.Setenv("COLORTERM", "truecolor")
+	}
+	// setup UI
+	g, err := gocui.NewGui(gocui.OutputTrue, true)
+	if err != nil {
+		log.Panicln(err)
+	}
+	defer g.Close()
+	gui = g // save pointer for use outside
+	g.FgColor = cFgColor
+	g.BgColor = cBgColor
+	g.Mouse = config.Mouse == nil || *config.Mouse
+
+	// prepare widgets
+	setupWorkspaces()
+	widgets = setupManagers()
+	setupLayout()
+	g.SetManagerFunc(handleLayouts)
+
+	hasWidgets := false
+	// set keybinds (after layout manager)
+	for _, w := range widgets {
+		w.Keybinds(g)
+		if w.GetName() != cmdView {
+			hasWidgets = true
+		}
+	}
+	keybindsGlobal(g)
+
+	// if no widget stack, show help
+	if !hasWidgets {
+		// this needs to be run at the end, because it handles all keybinds and layouts and stuff
+		PopupHelpWidget(nil)
+	}
+
+	// run startup script in the console
+	if script != "" {
+		g.Update(func(g *gocui.Gui) error {
+			return runStartupScript(g, script, keepGoing)
+		})
+	}
+
+	// main loop running
+	if err := g.MainLoop(); err != nil && !errors.Is(err, gocui.ErrQuit) {
+		g.Cursor = true
+		log.Panicln(err)
+	}
+	g.Cursor = true
+}
+
+// setupManagers prepare list of widgets where each of them manage its own layout and data
+func setupManagers() []Widgeter {
+	managers := []Widgeter{}
+
+	// add configured views (of all workspaces)
+	for _, wsp := range workspaces {
+		for vname, v := range wsp.views {
+			if w := getManager(managers, vname); w != nil {
+				// view names have to be unique (gocui views are shared)
+				if ws, ok := w.(*WidgetStack); ok {
+					ws.body += fmt.Sprintf("%v view '%v' in workspace '%v' skipped, name already used\n", colorText("error:", cErrorStr), vname, wsp.name)
+				}
+				continue
+			}
+			loading := fmt.Sprintf("Loading %v...\n", vname)
+			widget := NewWidgetStack(vname, v.Position, v.Size, loading)
+			widget.workspace = wsp.name
+			widget.Enabled = !v.Hidden
+			// setup highlight
+			widget.highlight = make(map[string]bool)
+			for _, hi := range v.HiWord {
+				widget.highlight[hi] = false
+			}
+			for _, hi := range v.HiLine {
+				widget.highlight[hi] = true
+			}
+			// setup change detection
+			if v.WatchDiff {
+				widget.diff = newWatchDiff(v.WatchFade)
+			}
+			widget.stripAnsi = v.StripAnsi
+			// setup refresh (before job)
+			if err := widget.SetRefresh(v); err != nil {
+				widget.body += colorText("error: ", cErrorStr) + err.Error() + "\n"
+			}
+			if err := widget.SetJob(v); err != nil {
+				widget.body += colorText("error: ", cErrorStr) + err.Error() + "\n"
+			}
+			if err := widget.SetAlerts(v); err != nil {
+				widget.body += colorText("error: ", cErrorStr) + err.Error() + "\n"
+			}
+			if v.Paused {
+				// job doesn't load anything, configuration errors are kept
+				widget.paused = true
+				widget.body = strings.TrimPrefix(widget.body, loading) + fmt.Sprintf("View %v is paused (Ctrl+Z to resume)...\n", vname)
+			}
+			// setup output rendering (before job)
+			if err := widget.SetRender(v); err != nil {
+				widget.body += colorText("error: ", cErrorStr) + err.Error() + "\n"
+			}
+			// setup job for view ;)
+			widget.SetupFun(v.Job)
+			// paused workspaces run the job only once
+			if wsp != currWorkspace && wsp.inactive == "pause" {
+				widget.StopFun()
+			}
+
+			// add to manager list
+			managers = append(managers, widget)
+		}
+	}
+	sortWidgetManager(managers)
+
+	// add console widget
+	managers = append(managers, NewWidgetConsole())
+	return managers
+}
+
+// Handle layouts of all the widgets (called by managerFunc)
+func handleLayouts(g *gocui.Gui) error {
+	if err := layoutMouse(g); err != nil {
+		return err
+	}
+	if err := layoutTabs(g); err != nil {
+		return err
+	}
+	arrangeLayout(g)
+	for _, w := range widgets {
+		if err := w.Layout(g); err != nil {
+			return err
+		}
+	}
+	if err := layoutSelection(g); err != nil {
+		return err
+	}
+	// handle cursor visibility (for editable only)
+	if v := g.CurrentView(); v != nil {
+		if v.Editable {
+			g.Cursor = true
+		} else {
+			g.Cursor = false
+		}
+	}
+	return nil
+}
+
+func getWidgetManager(name string) Widgeter {
+	return getManager(widgets, name)
+}
+
+func getManager(managers []Widgeter, name string) Widgeter {
+	for _, w := range managers {
+		if w.GetName() == name {
+			return w
+		}
+	}
+	return nil
+}
+
+// Sort WidgetStacks in Widgeter list
+func sortWidgetManager(managers []Widgeter) {
+	sort.Slice(managers, func(i, j int) bool {
+		return managers[i].Position() < managers[j].Position()
+	})
+}
+
+func getWidgetView(name string) *gocui.View {
+	for _, w := range widgets {
+		if w.GetName() == name {
+			return w.GetView()
+		}
+	}
+	return nil
+}
+
+func getConsoleWidget() *WidgetConsole {
+	for _, w := range widgets {
+		if w.GetName() == cmdView {
+			if wc, ok := w.(*WidgetConsole); ok {
+				return wc
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func getWidgetStack(name string) *WidgetStack {
+	for _, w := range widgets {
+		if w.GetName() == name {
+			if ws, ok := w.(*WidgetStack); ok {
+				return ws
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func getSortedWidgetStack() (wlist []*WidgetStack) {
+	for _, w := range widgets {
+		if ws, ok := w.(*WidgetStack); ok && ws.workspace == currWorkspace.name {
+			wlist = append(wlist, ws)
+		}
+	}
+	sort.Slice(wlist, func(i, j int) bool {
+		return wlist[i].pos < wlist[j].pos
+	})
+	return
+}
+
+func getVisibleWidgetStack() (wlist []*WidgetStack) {
+	for _, ws := range getSortedWidgetStack() {
+		if !ws.IsHidden() {
+			wlist = append(wlist, ws)
+		}
+	}
+	return
+}